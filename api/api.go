@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -19,21 +20,24 @@ func (w *loggingResponseWriter) WriteHeader(code int) {
 }
 
 type APIServer struct {
-	addr string
+	cfg Config
 }
 
-func NewAPIServer(addr string) *APIServer {
+func NewAPIServer(cfg Config) *APIServer {
 	return &APIServer{
-		addr: addr,
+		cfg: cfg,
 	}
 }
 
-func (s *APIServer) Run() error {
+// Run serves the API until ctx is cancelled, then shuts down gracefully:
+// clients are told the server is restarting, lobbies are saved and the HTTP
+// server is given cfg.ShutdownTimeout to finish.
+func (s *APIServer) Run(ctx context.Context) error {
 	router := chi.NewRouter()
 	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowedOrigins:   s.cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Set-Cookie"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Host-Token", "Set-Cookie"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 	}))
@@ -42,19 +46,42 @@ func (s *APIServer) Run() error {
 	router.Mount("/api/v1", baseRouter)
 
 	// lobby manager and routes
-	lm := NewLobbyManager()
+	lm := NewLobbyManager(s.cfg)
 	baseRouter.Post("/lobbies", lm.CreateLobby)
+	baseRouter.Get("/wordpacks", lm.ListWordPacks)
 	baseRouter.Get("/lobbies/{code}", lm.GetLobby)
 	baseRouter.Post("/lobbies/{code}/start", lm.StartGame)
 	baseRouter.Post("/lobbies/{code}/end", lm.EndGame)
 	baseRouter.Post("/lobbies/{code}/restart", lm.RestartGame)
+	baseRouter.Get("/lobbies/{code}/scoreboard", lm.GetScoreboard)
+	baseRouter.Put("/lobbies/{code}/words", lm.SetCustomWords)
+	baseRouter.Get("/lobbies/{code}/words", lm.GetCustomWords)
+	baseRouter.Delete("/lobbies/{code}/words", lm.DeleteCustomWords)
+	baseRouter.Get("/lobbies/{code}/history", lm.GetWordHistory)
 	// websocket endpoint: /api/v1/ws/{code}?name=alice
 	baseRouter.Get("/ws/{code}", lm.ServeWS)
 
 	// wrap router with embedded static file handler (serves ui/dist)
 	handler := s.serveUI(router)
 
-	log.Println("listening on", s.addr)
+	srv := &http.Server{Addr: s.cfg.Addr, Handler: handler}
+	errc := make(chan error, 1)
+	go func() {
+		log.Println("listening on", s.cfg.Addr)
+		errc <- srv.ListenAndServe()
+	}()
 
-	return http.ListenAndServe(s.addr, handler)
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := lm.Shutdown(shutdownCtx, s.cfg.RestartETA); err != nil {
+		log.Println("failed to shut down lobbies:", err)
+	}
+	return srv.Shutdown(shutdownCtx)
 }
@@ -2,27 +2,57 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/gorilla/websocket"
+
+	"imposter/api/protocol"
 )
 
+// testConfig is the default configuration with six-letter lobby codes and
+// no event log
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.CodeLength = 6
+	cfg.LogPath = ""
+	cfg.LogSink = SinkFile
+	return cfg
+}
+
 // Helper function to create a test router
 func setupTestRouter() *chi.Mux {
+	return newTestRouter(NewLobbyManager(testConfig()))
+}
+
+// newTestRouter mounts the API routes for lm
+func newTestRouter(lm *LobbyManager) *chi.Mux {
 	router := chi.NewRouter()
 	baseRouter := chi.NewRouter()
-	lm := NewLobbyManager()
 	baseRouter.Post("/lobbies", lm.CreateLobby)
+	baseRouter.Get("/wordpacks", lm.ListWordPacks)
 	baseRouter.Get("/lobbies/{code}", lm.GetLobby)
 	baseRouter.Post("/lobbies/{code}/start", lm.StartGame)
 	baseRouter.Post("/lobbies/{code}/end", lm.EndGame)
 	baseRouter.Post("/lobbies/{code}/restart", lm.RestartGame)
+	baseRouter.Get("/lobbies/{code}/scoreboard", lm.GetScoreboard)
+	baseRouter.Put("/lobbies/{code}/words", lm.SetCustomWords)
+	baseRouter.Get("/lobbies/{code}/words", lm.GetCustomWords)
+	baseRouter.Delete("/lobbies/{code}/words", lm.DeleteCustomWords)
+	baseRouter.Get("/lobbies/{code}/history", lm.GetWordHistory)
 	baseRouter.Get("/ws/{code}", lm.ServeWS)
 	router.Mount("/api/v1", baseRouter)
 	return router
@@ -303,6 +333,7 @@ func TestStartGameFlow(t *testing.T) {
 
 	body := bytes.NewBufferString(`{"imposters": 1}`)
 	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/start", body)
+	req.Header.Set("X-Host-Token", createResp.HostToken)
 	req.Header.Set("Content-Type", "application/json")
 	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -367,6 +398,7 @@ func TestStartGameInvalidImposters(t *testing.T) {
 	// Try to start game with too many imposters (more than players)
 	body := bytes.NewBufferString(`{"imposters": 5}`)
 	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/start", body)
+	req.Header.Set("X-Host-Token", createResp.HostToken)
 	req.Header.Set("Content-Type", "application/json")
 	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -435,7 +467,7 @@ func TestCompleteGameFlow(t *testing.T) {
 	}
 	defer hostWS.Close()
 
-	hostWS.WriteJSON(map[string]string{"type": "join", "name": "Host"})
+	hostWS.WriteJSON(map[string]string{"type": "join", "name": "Host", "host_token": createResp.HostToken})
 	var hostMsg map[string]interface{}
 	hostWS.ReadJSON(&hostMsg)
 
@@ -473,6 +505,7 @@ func TestCompleteGameFlow(t *testing.T) {
 	// Step 4: Host starts game with 1 imposter
 	body := bytes.NewBufferString(`{"imposters": 1}`)
 	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/start", body)
+	req.Header.Set("X-Host-Token", createResp.HostToken)
 	req.Header.Set("Content-Type", "application/json")
 	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -555,7 +588,7 @@ func TestEndAndRestartFlow(t *testing.T) {
 		t.Fatal("host failed to connect:", err)
 	}
 	defer hostWS.Close()
-	hostWS.WriteJSON(map[string]string{"type": "join", "name": "Host"})
+	hostWS.WriteJSON(map[string]string{"type": "join", "name": "Host", "host_token": createResp.HostToken})
 	var hostMsg map[string]interface{}
 	hostWS.ReadJSON(&hostMsg)
 
@@ -577,6 +610,7 @@ func TestEndAndRestartFlow(t *testing.T) {
 	// Start game with 1 imposter
 	body := bytes.NewBufferString(`{"imposters": 1}`)
 	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/start", body)
+	req.Header.Set("X-Host-Token", createResp.HostToken)
 	req.Header.Set("Content-Type", "application/json")
 	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -599,6 +633,7 @@ func TestEndAndRestartFlow(t *testing.T) {
 
 	// Now call restart
 	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/restart", nil)
+	req.Header.Set("X-Host-Token", createResp.HostToken)
 	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	if w.Code != http.StatusOK {
@@ -626,6 +661,7 @@ func TestEndAndRestartFlow(t *testing.T) {
 
 	// Now call end
 	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/end", nil)
+	req.Header.Set("X-Host-Token", createResp.HostToken)
 	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	if w.Code != http.StatusOK {
@@ -682,3 +718,1963 @@ func TestEndAndRestartFlow(t *testing.T) {
 
 	t.Log("✓ End and Restart flow works")
 }
+
+// readUntil reads messages from ws until one of the given type arrives
+func readUntil(t *testing.T, ws *websocket.Conn, msgType string) map[string]interface{} {
+	t.Helper()
+	_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	defer ws.SetReadDeadline(time.Time{})
+	for {
+		var msg map[string]interface{}
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatalf("failed waiting for %s: %v", msgType, err)
+		}
+		if msg["type"] == msgType {
+			return msg
+		}
+	}
+}
+
+// startTestGame creates a lobby, connects the host and the named players and
+// starts a game with body. It returns the lobby code, the host connection,
+// each player's connection and each player's game_started message.
+func startTestGame(t *testing.T, router *chi.Mux, server *httptest.Server, names []string, body string) (string, *websocket.Conn, map[string]*websocket.Conn, map[string]map[string]interface{}) {
+	t.Helper()
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+	code := createResp.Code
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/" + code
+
+	hostWS, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=Host&host_token="+createResp.HostToken, nil)
+	if err != nil {
+		t.Fatal("host failed to connect:", err)
+	}
+	t.Cleanup(func() { hostWS.Close() })
+	readUntil(t, hostWS, "host_ready")
+
+	playerWSs := map[string]*websocket.Conn{}
+	for _, name := range names {
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?name="+name, nil)
+		if err != nil {
+			t.Fatalf("failed to connect %s: %v", name, err)
+		}
+		t.Cleanup(func() { ws.Close() })
+		readUntil(t, ws, "lobby_state")
+		playerWSs[name] = ws
+	}
+
+	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/start", bytes.NewBufferString(body))
+	req.Header.Set("X-Host-Token", createResp.HostToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to start game: %d %s", w.Code, w.Body.String())
+	}
+
+	started := map[string]map[string]interface{}{}
+	for _, name := range names {
+		started[name] = readUntil(t, playerWSs[name], "game_started")
+	}
+	readUntil(t, hostWS, "game_started")
+
+	return code, hostWS, playerWSs, started
+}
+
+// plural returns the regular English plural of word
+func plural(word string) string {
+	switch {
+	case len(word) > 1 && strings.HasSuffix(word, "y") && !strings.ContainsAny(word[len(word)-2:len(word)-1], "aeiou"):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s") || strings.HasSuffix(word, "x") || strings.HasSuffix(word, "z") ||
+		strings.HasSuffix(word, "ch") || strings.HasSuffix(word, "sh"):
+		return word + "es"
+	}
+	return word + "s"
+}
+
+// voteOut has every player vote for suspect (the suspect picks someone else)
+// and returns the vote_result seen by the host
+func voteOut(t *testing.T, hostWS *websocket.Conn, playerWSs map[string]*websocket.Conn, suspect string) map[string]interface{} {
+	t.Helper()
+	hostWS.WriteJSON(map[string]string{"type": "open_vote"})
+	for name, ws := range playerWSs {
+		readUntil(t, ws, "vote_opened")
+		vote := suspect
+		if name == suspect {
+			for other := range playerWSs {
+				if other != suspect {
+					vote = other
+					break
+				}
+			}
+		}
+		ws.WriteJSON(map[string]string{"type": "cast_vote", "suspect": vote})
+	}
+	return readUntil(t, hostWS, "vote_result")
+}
+
+// TestVotingFlow tests that a vote eliminates the top suspect and reveals their role
+func TestVotingFlow(t *testing.T) {
+	router := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	names := []string{"P1", "P2", "P3"}
+	_, hostWS, playerWSs, started := startTestGame(t, router, server, names, `{"imposters": 1, "tie_rule": "none"}`)
+
+	imposter := ""
+	for name, msg := range started {
+		if msg["role"] == "imposter" {
+			imposter = name
+		}
+	}
+	if imposter == "" {
+		t.Fatal("no imposter assigned")
+	}
+
+	result := voteOut(t, hostWS, playerWSs, imposter)
+	if result["eliminated"] != imposter {
+		t.Fatalf("expected %s to be eliminated, got %v", imposter, result["eliminated"])
+	}
+	if result["role"] != "imposter" {
+		t.Fatalf("expected revealed role imposter, got %v", result["role"])
+	}
+	if result["guess_pending"] != true || result["winner"] != "" {
+		t.Fatalf("expected the outcome to wait for the imposter's guess, got %v", result)
+	}
+
+	// a wrong guess leaves the win with the word players
+	playerWSs[imposter].WriteJSON(map[string]string{"type": "guess_word", "guess": "definitely not it"})
+	guess := readUntil(t, hostWS, "guess_result")
+	if guess["correct"] != false || guess["winner"] != "word" {
+		t.Fatalf("expected wrong guess and word players to win, got %v", guess)
+	}
+
+	t.Logf("✓ Vote eliminated %s and word players won", imposter)
+}
+
+// TestImposterGuessStealsWin tests that a caught imposter naming the word wins the round
+func TestImposterGuessStealsWin(t *testing.T) {
+	router := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	names := []string{"P1", "P2", "P3"}
+	_, hostWS, playerWSs, started := startTestGame(t, router, server, names, `{"imposters": 1}`)
+
+	imposter, word := "", ""
+	for name, msg := range started {
+		if msg["role"] == "imposter" {
+			imposter = name
+		} else {
+			word = msg["word"].(string)
+		}
+	}
+
+	voteOut(t, hostWS, playerWSs, imposter)
+
+	// only the caught imposter may guess
+	for name, ws := range playerWSs {
+		if name != imposter {
+			ws.WriteJSON(map[string]string{"type": "guess_word", "guess": word})
+			if msg := readUntil(t, ws, "error"); msg["error"] == nil {
+				t.Fatalf("expected %s's guess to be rejected", name)
+			}
+			break
+		}
+	}
+
+	playerWSs[imposter].WriteJSON(map[string]string{"type": "guess_word", "guess": "  " + strings.ToUpper(plural(word)) + " "})
+	for _, ws := range append([]*websocket.Conn{hostWS}, playerWSs[imposter]) {
+		guess := readUntil(t, ws, "guess_result")
+		if guess["correct"] != true || guess["winner"] != "imposters" {
+			t.Fatalf("expected correct guess and imposters to win, got %v", guess)
+		}
+		if guess["word"] != word {
+			t.Fatalf("expected word %q to be revealed, got %v", word, guess["word"])
+		}
+	}
+
+	t.Logf("✓ Imposter %s stole the win by guessing %q", imposter, word)
+}
+
+// TestWordsMatch tests guess normalisation
+func TestWordsMatch(t *testing.T) {
+	cases := []struct {
+		guess, word string
+		want        bool
+	}{
+		{"Banana", "banana", true},
+		{"  ice   cream ", "ice cream", true},
+		{"icecream", "ice cream", true},
+		{"Hot-Dogs", "hotdog", true},
+		{"strawberries", "strawberry", true},
+		{"tomatoes", "tomato", true},
+		{"sandwiches", "sandwich", true},
+		{"colour", "color", true},
+		{"Whisky", "whiskey", true},
+		{"T-Shirt", "t-shirt", true},
+		{"glass", "glasses", true},
+		{"cookies", "cookie", true},
+		{"hoodies", "hoodie", true},
+		{"beanies", "beanie", true},
+		{"prairies", "prairie", true},
+		{"kiwis", "kiwi", true},
+		{"taxis", "taxi", true},
+		{"tsunamis", "tsunami", true},
+		{"martinis", "martini", true},
+		{"buses", "bus", true},
+		{"avalanches", "avalanche", true},
+		{"lenses", "lens", true},
+		{"tennis", "tennis", true},
+		{"apple", "banana", false},
+		{"", "banana", false},
+		{"   ", "banana", false},
+	}
+	for _, c := range cases {
+		if got := wordsMatch(c.guess, c.word); got != c.want {
+			t.Errorf("wordsMatch(%q, %q) = %v, want %v", c.guess, c.word, got, c.want)
+		}
+	}
+
+	t.Log("✓ Guess normalisation matches variants")
+}
+
+// TestStartGameInvalidTieRule tests validation of the tie rule
+func TestStartGameInvalidTieRule(t *testing.T) {
+	router := setupTestRouter()
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+
+	body := bytes.NewBufferString(`{"imposters": 1, "tie_rule": "coin toss"}`)
+	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+createResp.Code+"/start", body)
+	req.Header.Set("X-Host-Token", createResp.HostToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for invalid tie rule, got %d", w.Code)
+	}
+
+	t.Log("✓ Invalid tie rule properly rejected")
+}
+
+// TestScoreboard tests that finished rounds are scored and survive a player leaving
+func TestScoreboard(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	lm.reconnectGrace = 50 * time.Millisecond
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	names := []string{"P1", "P2", "P3"}
+	body := `{"imposters": 1, "points": {"word_win": 3, "imposter_win": 5, "correct_vote": 1, "imposter_guess": 2}}`
+	code, hostWS, playerWSs, started := startTestGame(t, router, server, names, body)
+
+	imposter := ""
+	for name, msg := range started {
+		if msg["role"] == "imposter" {
+			imposter = name
+		}
+	}
+
+	voteOut(t, hostWS, playerWSs, imposter)
+	hostWS.WriteJSON(map[string]string{"type": "skip_guess"})
+
+	board := readUntil(t, hostWS, "scoreboard")
+	rounds := board["rounds"].([]interface{})
+	if len(rounds) != 1 || rounds[0].(map[string]interface{})["winner"] != "word" {
+		t.Fatalf("expected one round won by word players, got %v", board["rounds"])
+	}
+
+	// a word player leaves; their points must stay on the board
+	leaver := ""
+	for _, name := range names {
+		if name != imposter {
+			leaver = name
+			break
+		}
+	}
+	playerWSs[leaver].Close()
+	readUntil(t, hostWS, "lobby_state")
+
+	req, _ := http.NewRequest("GET", "/api/v1/lobbies/"+code+"/scoreboard", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Scores []struct {
+			Name   string `json:"name"`
+			Points int    `json:"points"`
+		} `json:"scores"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal("failed to decode scoreboard:", err)
+	}
+
+	scores := map[string]int{}
+	for _, s := range resp.Scores {
+		scores[s.Name] = s.Points
+	}
+	for _, name := range names {
+		want := 4 // word win + correct vote
+		if name == imposter {
+			want = 0
+		}
+		if scores[name] != want {
+			t.Fatalf("expected %s to have %d points, got %v", name, want, scores)
+		}
+	}
+
+	t.Logf("✓ Scoreboard after one round: %v", scores)
+}
+
+// TestWordPacks tests listing packs and starting a game from a chosen pack
+func TestWordPacks(t *testing.T) {
+	router := setupTestRouter()
+	req, _ := http.NewRequest("GET", "/api/v1/wordpacks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var packs []struct {
+		Name      string `json:"name"`
+		Category  string `json:"category"`
+		WordCount int    `json:"word_count"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&packs); err != nil {
+		t.Fatal("failed to decode word packs:", err)
+	}
+	found := false
+	for _, p := range packs {
+		if p.Name == "fruit" && p.Category == "fruit" && p.WordCount > 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected the fruit pack to be listed, got %v", packs)
+	}
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	_, _, _, started := startTestGame(t, router, server, []string{"P1", "P2", "P3"}, `{"imposters": 1, "packs": ["fruit"]}`)
+
+	wp, _ := LoadWordPacks("")
+	fruit, _ := wp.Pool([]string{"fruit"})
+	for _, msg := range started {
+		if msg["role"] != "word" {
+			continue
+		}
+		found := false
+		for _, w := range fruit {
+			found = found || w.Text == msg["word"]
+		}
+		if !found {
+			t.Fatalf("expected a fruit word, got %v", msg["word"])
+		}
+	}
+
+	t.Logf("✓ %d word packs listed and game drew from the fruit pack", len(packs))
+}
+
+// TestStartGameUnknownPack tests that unknown packs are rejected
+func TestStartGameUnknownPack(t *testing.T) {
+	router := setupTestRouter()
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+
+	body := bytes.NewBufferString(`{"imposters": 1, "packs": ["no-such-pack"]}`)
+	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+createResp.Code+"/start", body)
+	req.Header.Set("X-Host-Token", createResp.HostToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for unknown pack, got %d", w.Code)
+	}
+
+	t.Log("✓ Unknown word pack properly rejected")
+}
+
+// TestLoadWordPacksDir tests loading, validating and de-duplicating packs from a directory
+func TestLoadWordPacksDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "team.json"), []byte(`{"name": "team", "category": "codename", "words": ["Falcon", " falcon ", "Osprey", ""]}`), 0644)
+
+	wp, err := LoadWordPacks(dir)
+	if err != nil {
+		t.Fatal("failed to load word packs:", err)
+	}
+	words, _ := wp.Pool([]string{"team"})
+	if len(words) != 2 {
+		t.Fatalf("expected duplicates to be removed, got %v", words)
+	}
+
+	os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{"name": "fruit", "words": ["apple"]}`), 0644)
+	if _, err := LoadWordPacks(dir); err == nil {
+		t.Fatal("expected a pack clashing with an embedded pack name to be rejected")
+	}
+
+	dir = t.TempDir()
+	os.WriteFile(filepath.Join(dir, "birds.yaml"), []byte("name: birds\ncategory: bird\ndifficulty: easy\nwords:\n  - Falcon\n  - falcon\ngroups:\n  - [Owl, Osprey]\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "fish.yml"), []byte("name: fish\nwords: [Cod, Hake]\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a pack"), 0644)
+	wp, err = LoadWordPacks(dir)
+	if err != nil {
+		t.Fatal("failed to load YAML word packs:", err)
+	}
+	birds, _ := wp.Pool([]string{"birds"})
+	groups, _ := wp.GroupPool([]string{"birds"})
+	fish, _ := wp.Pool([]string{"fish"})
+	if len(birds) != 3 || birds[0].Category != "bird" || len(groups) != 1 || len(fish) != 2 {
+		t.Fatalf("expected the YAML packs loaded like JSON ones, got %v %v %v", birds, groups, fish)
+	}
+
+	os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("name: Bad Name\nwords: [x]\n"), 0644)
+	if _, err := LoadWordPacks(dir); err == nil {
+		t.Fatal("expected a YAML pack with an invalid name to be rejected")
+	}
+
+	t.Logf("✓ Loaded custom pack with words %v", words)
+}
+
+// TestCustomWords tests uploading a custom word list and drawing from it
+func TestCustomWords(t *testing.T) {
+	router := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+	code := createResp.Code
+
+	uploads := []struct {
+		contentType, body string
+		want              int
+	}{
+		{"text/plain", "Project Falcon\nosprey, OSPREY\n\n  heron\x07  \n", 3},
+		{"application/json", `["Falcon", "Osprey"]`, 2},
+		{"application/json", `{"words": ["Falcon"]}`, 1},
+		{"text/csv", "Falcon,Osprey\nHeron,\"Kite, Red\"\n", 4},
+	}
+	for _, u := range uploads {
+		req, _ = http.NewRequest("PUT", "/api/v1/lobbies/"+code+"/words", strings.NewReader(u.body))
+		req.Header.Set("X-Host-Token", createResp.HostToken)
+		req.Header.Set("Content-Type", u.contentType)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var resp struct {
+			Count int `json:"count"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusOK || resp.Count != u.want {
+			t.Fatalf("%s upload: expected %d words, got status %d count %d", u.contentType, u.want, w.Code, resp.Count)
+		}
+	}
+
+	// too many words
+	many := make([]string, maxCustomWords+1)
+	for i := range many {
+		many[i] = "word" + strconv.Itoa(i)
+	}
+	req, _ = http.NewRequest("PUT", "/api/v1/lobbies/"+code+"/words", strings.NewReader(strings.Join(many, "\n")))
+	req.Header.Set("X-Host-Token", createResp.HostToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected oversized list to be rejected, got %d", w.Code)
+	}
+
+	// start a game drawing only from the custom list
+	req, _ = http.NewRequest("PUT", "/api/v1/lobbies/"+code+"/words", strings.NewReader(`["Falcon"]`))
+	req.Header.Set("X-Host-Token", createResp.HostToken)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// the custom list is not part of the public lobby view
+	req, _ = http.NewRequest("GET", "/api/v1/lobbies/"+code, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), "Falcon") {
+		t.Fatalf("custom words leaked in lobby view: %s", w.Body.String())
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/" + code
+	players := []*websocket.Conn{}
+	for _, name := range []string{"P1", "P2", "P3"} {
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?name="+name, nil)
+		if err != nil {
+			t.Fatalf("failed to connect %s: %v", name, err)
+		}
+		defer ws.Close()
+		readUntil(t, ws, "lobby_state")
+		players = append(players, ws)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/start", bytes.NewBufferString(`{"imposters": 1, "custom_words": true}`))
+	req.Header.Set("X-Host-Token", createResp.HostToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to start game: %d %s", w.Code, w.Body.String())
+	}
+	for _, ws := range players {
+		msg := readUntil(t, ws, "game_started")
+		if msg["role"] == "word" && msg["word"] != "Falcon" {
+			t.Fatalf("expected custom word Falcon, got %v", msg["word"])
+		}
+	}
+
+	t.Log("✓ Custom word list uploaded and used")
+}
+
+// TestUndercoverMode tests that imposters get a related decoy word in an identical payload
+func TestUndercoverMode(t *testing.T) {
+	router := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	names := []string{"P1", "P2", "P3", "P4"}
+	_, _, _, started := startTestGame(t, router, server, names, `{"imposters": 1, "mode": "undercover", "packs": ["animals"]}`)
+
+	counts := map[string]int{}
+	for name, msg := range started {
+		if msg["role"] != "word" {
+			t.Fatalf("expected every player to see role word, %s got %v", name, msg["role"])
+		}
+		if len(msg) != 5 {
+			t.Fatalf("expected identical payload shape, %s got %v", name, msg)
+		}
+		counts[msg["word"].(string)]++
+	}
+	if len(counts) != 2 {
+		t.Fatalf("expected a real word and a decoy, got %v", counts)
+	}
+
+	wp, _ := LoadWordPacks("")
+	groups, _ := wp.GroupPool([]string{"animals"})
+	related := false
+	for _, g := range groups {
+		n := 0
+		for w := range counts {
+			if contains(g.Words, w) {
+				n++
+			}
+		}
+		if n == 2 {
+			related = true
+		}
+	}
+	if !related {
+		t.Fatalf("expected decoy to be related to the word, got %v", counts)
+	}
+
+	t.Logf("✓ Undercover words dealt: %v", counts)
+}
+
+// TestCategoryHint tests that imposters are told the category of the word
+func TestCategoryHint(t *testing.T) {
+	router := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	names := []string{"P1", "P2", "P3"}
+	_, _, _, started := startTestGame(t, router, server, names, `{"imposters": 1, "packs": ["fruit"], "hint": "imposters"}`)
+	for name, msg := range started {
+		_, hasCategory := msg["category"]
+		if msg["role"] == "imposter" && msg["category"] != "fruit" {
+			t.Fatalf("expected imposter %s to get category fruit, got %v", name, msg)
+		}
+		if msg["role"] == "word" && hasCategory {
+			t.Fatalf("expected word player %s not to get the category, got %v", name, msg)
+		}
+	}
+
+	_, _, _, started = startTestGame(t, router, server, names, `{"imposters": 1, "packs": ["fruit"], "hint": "everyone"}`)
+	for name, msg := range started {
+		if msg["category"] != "fruit" {
+			t.Fatalf("expected %s to get category fruit, got %v", name, msg)
+		}
+	}
+
+	t.Log("✓ Category hint sent to the right players")
+}
+
+// TestReconnectWithToken tests that a dropped player resumes their seat, role and word
+func TestReconnectWithToken(t *testing.T) {
+	router := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+	code := createResp.Code
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/" + code
+
+	hostWS, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=Host&host_token="+createResp.HostToken, nil)
+	if err != nil {
+		t.Fatal("host failed to connect:", err)
+	}
+	defer hostWS.Close()
+	hostReady := readUntil(t, hostWS, "host_ready")
+	if hostReady["token"] == "" {
+		t.Fatal("expected host_ready to carry a session token")
+	}
+
+	tokens := map[string]string{}
+	players := map[string]*websocket.Conn{}
+	for _, name := range []string{"P1", "P2", "P3"} {
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?name="+name, nil)
+		if err != nil {
+			t.Fatalf("failed to connect %s: %v", name, err)
+		}
+		defer ws.Close()
+		state := readUntil(t, ws, "lobby_state")
+		tokens[name] = state["token"].(string)
+		players[name] = ws
+	}
+	if tokens["P1"] == "" || tokens["P1"] == tokens["P2"] {
+		t.Fatalf("expected distinct session tokens, got %v", tokens)
+	}
+
+	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/start", bytes.NewBufferString(`{"imposters": 1}`))
+	req.Header.Set("X-Host-Token", createResp.HostToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to start game: %d", w.Code)
+	}
+	before := readUntil(t, players["P1"], "game_started")
+	readUntil(t, hostWS, "game_started")
+
+	// P1 refreshes the page; the host sees P1 drop
+	players["P1"].Close()
+	if msg := readUntil(t, hostWS, "player_status"); msg["name"] != "P1" || msg["status"] != "away" {
+		t.Fatalf("expected P1 away, got %v", msg)
+	}
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?token="+tokens["P1"], nil)
+	if err != nil {
+		t.Fatal("failed to reconnect:", err)
+	}
+	defer ws.Close()
+
+	after := readUntil(t, ws, "game_started")
+	if after["role"] != before["role"] || after["word"] != before["word"] {
+		t.Fatalf("expected same role and word after reconnect, got %v then %v", before, after)
+	}
+	state := readUntil(t, ws, "lobby_state")
+	if len(state["players"].([]interface{})) != 3 || state["token"] != tokens["P1"] {
+		t.Fatalf("expected to resume the same seat, got %v", state)
+	}
+
+	// and come back, without any lobby churn
+	if msg := readUntil(t, hostWS, "player_status"); msg["name"] != "P1" || msg["status"] != "online" {
+		t.Fatalf("expected P1 online, got %v", msg)
+	}
+	_ = hostWS.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	var msg map[string]interface{}
+	if err := hostWS.ReadJSON(&msg); err == nil {
+		t.Fatalf("expected no more messages for host after reconnect, got %v", msg)
+	}
+
+	t.Log("✓ Player reconnected with session token and kept their seat")
+}
+
+// TestStaleTokenRejected tests that a released seat's token can't take the
+// name back from whoever joined with it since
+func TestStaleTokenRejected(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	lm.reconnectGrace = 50 * time.Millisecond
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/" + createResp.Code
+
+	hostWS, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=Host&host_token="+createResp.HostToken, nil)
+	if err != nil {
+		t.Fatal("host failed to connect:", err)
+	}
+	defer hostWS.Close()
+	readUntil(t, hostWS, "host_ready")
+
+	old, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=P1", nil)
+	if err != nil {
+		t.Fatal("P1 failed to connect:", err)
+	}
+	token := readUntil(t, old, "lobby_state")["token"].(string)
+	old.Close()
+	for _, status := range []string{"away", "gone"} {
+		if msg := readUntil(t, hostWS, "player_status"); msg["status"] != status {
+			t.Fatalf("expected P1 %s, got %v", status, msg)
+		}
+	}
+
+	// someone else joins as P1 once the seat is released
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=P1", nil)
+	if err != nil {
+		t.Fatal("new P1 failed to connect:", err)
+	}
+	defer ws.Close()
+	if state := readUntil(t, ws, "lobby_state"); state["token"] == token {
+		t.Fatal("expected a new session token")
+	}
+
+	stale, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=P1&token="+token, nil)
+	if err != nil {
+		t.Fatal("failed to connect with the old token:", err)
+	}
+	defer stale.Close()
+	if msg := readUntil(t, stale, "join_rejected"); msg["reason"] != RejectNameTaken {
+		t.Fatalf("expected the old token to be refused, got %v", msg)
+	}
+
+	l, _ := lm.store.Get(createResp.Code)
+	l.mu.Lock()
+	connected, seats := l.connected("P1"), len(l.Players)
+	l.mu.Unlock()
+	if !connected || seats != 1 {
+		t.Fatalf("expected the new P1 to keep their seat, got connected %v with %d seats", connected, seats)
+	}
+
+	t.Log("✓ Old session token refused after its seat was released")
+}
+
+// TestHostAuth tests that lobby controls and the host socket need the host token
+func TestHostAuth(t *testing.T) {
+	router := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+	code := createResp.Code
+	if createResp.HostToken == "" {
+		t.Fatal("expected a host token when creating a lobby")
+	}
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "imposter_host_"+code {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != createResp.HostToken || !cookie.HttpOnly {
+		t.Fatalf("expected an http-only host cookie, got %v", w.Result().Cookies())
+	}
+
+	// control endpoints without or with a wrong token
+	for _, path := range []string{"/start", "/end", "/restart", "/words"} {
+		method := "POST"
+		if path == "/words" {
+			method = "GET"
+		}
+		req, _ = http.NewRequest(method, "/api/v1/lobbies/"+code+path, bytes.NewBufferString(`{"imposters": 1}`))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for %s without token, got %d", path, w.Code)
+		}
+
+		req, _ = http.NewRequest(method, "/api/v1/lobbies/"+code+path, bytes.NewBufferString(`{"imposters": 1}`))
+		req.Header.Set("X-Host-Token", "not-the-token")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("expected 403 for %s with wrong token, got %d", path, w.Code)
+		}
+	}
+
+	// the cookie and a bearer token are accepted too
+	req, _ = http.NewRequest("POST", "/api/v1/lobbies/"+code+"/end", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected cookie to authorize end, got %d", w.Code)
+	}
+	req, _ = http.NewRequest("GET", "/api/v1/lobbies/"+code+"/words", nil)
+	req.Header.Set("Authorization", "Bearer "+createResp.HostToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected bearer token to authorize words, got %d", w.Code)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/" + code
+	hostWS, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=Host&host_token="+createResp.HostToken, nil)
+	if err != nil {
+		t.Fatal("host failed to connect:", err)
+	}
+	defer hostWS.Close()
+	readUntil(t, hostWS, "host_ready")
+	readUntil(t, hostWS, "lobby_state")
+
+	// impostor hosts are turned away, by query param or join message
+	for _, join := range []string{"?name=Host", "?name=Host&host_token=guess", ""} {
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL+join, nil)
+		if err != nil {
+			t.Fatal("failed to connect:", err)
+		}
+		if join == "" {
+			ws.WriteJSON(map[string]string{"type": "join", "name": "Host", "host_token": "guess"})
+		}
+		var msg map[string]interface{}
+		ws.ReadJSON(&msg)
+		if msg["error"] == nil || msg["type"] == "host_ready" {
+			t.Fatalf("expected impostor host %q to be rejected, got %v", join, msg)
+		}
+		ws.Close()
+	}
+
+	// the real host still gets lobby updates
+	player, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=P1", nil)
+	if err != nil {
+		t.Fatal("player failed to connect:", err)
+	}
+	defer player.Close()
+	state := readUntil(t, hostWS, "lobby_state")
+	if players := state["players"].([]interface{}); len(players) != 1 || players[0] != "P1" {
+		t.Fatalf("expected host to see P1 join, got %v", state)
+	}
+
+	t.Log("✓ Host token required for lobby controls and host socket")
+}
+
+// TestJoinNameValidation tests that names are cleaned, unique and not reserved
+func TestJoinNameValidation(t *testing.T) {
+	router := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/" + createResp.Code
+
+	join := func(name string) map[string]interface{} {
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatal("failed to connect:", err)
+		}
+		t.Cleanup(func() { ws.Close() })
+		ws.WriteJSON(map[string]string{"type": "join", "name": name})
+		var msg map[string]interface{}
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatalf("no reply joining as %q: %v", name, err)
+		}
+		return msg
+	}
+
+	state := join("  Sam \t Smith ")
+	if state["type"] != "lobby_state" {
+		t.Fatalf("expected to join, got %v", state)
+	}
+	state = join("Zoé")
+	players := state["players"].([]interface{})
+	if players[0] != "Sam Smith" || players[1] != "Zoé" {
+		t.Fatalf("expected trimmed, NFC names, got %v", players)
+	}
+
+	cases := []struct {
+		name, reason, suggestion string
+	}{
+		{"sam smith", RejectNameTaken, "sam smith 2"},
+		{"ＳＡＭ ＳＭＩＴＨ", RejectNameTaken, "ＳＡＭ ＳＭＩＴＨ 2"},
+		{"zoé", RejectNameTaken, "zoé 2"},
+		{"host", RejectNameReserved, "host 2"},
+		{"HOST", RejectNameReserved, "HOST 2"},
+		{"Bartholomew Fitzgerald III", RejectNameTooLong, "Bartholomew Fitzgera"},
+		{"   ", RejectNameRequired, ""},
+		{"\u200b\u0007", RejectNameInvalid, ""},
+		{"!!!", RejectNameInvalid, ""},
+	}
+	for _, c := range cases {
+		msg := join(c.name)
+		if msg["type"] != "join_rejected" || msg["reason"] != c.reason || msg["suggestion"] != c.suggestion {
+			t.Fatalf("joining as %q: expected %s with suggestion %q, got %v", c.name, c.reason, c.suggestion, msg)
+		}
+		if msg["error"] == "" {
+			t.Fatalf("expected an error message for %q", c.name)
+		}
+	}
+
+	// the host's own name is the host slot, which needs the host token
+	if msg := join("Host"); msg["type"] != "error" || msg["reason"] != protocol.ReasonUnauthorized {
+		t.Fatalf("expected Host without a token to be unauthorized, got %v", msg)
+	}
+
+	// the suggestion itself is free
+	if msg := join("Sam Smith 2"); msg["type"] != "lobby_state" {
+		t.Fatalf("expected suggested name to be accepted, got %v", msg)
+	}
+
+	t.Log("✓ Player names validated on join")
+}
+
+// TestProtocolErrors tests that bad WebSocket messages get a typed error back
+func TestProtocolErrors(t *testing.T) {
+	router := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	code, hostWS, playerWSs, _ := startTestGame(t, router, server, []string{"P1", "P2", "P3"}, `{"imposters": 1}`)
+	p1 := playerWSs["P1"]
+
+	cases := []struct {
+		ws     *websocket.Conn
+		msg    string
+		reason string
+	}{
+		{p1, `{"type": "teleport"}`, "unknown_type"},
+		{p1, `{"type": "cast_vote", "suspect": 5}`, "malformed"},
+		{p1, `{"type": "cast_vote", "v": 7, "suspect": "P2"}`, "unsupported_version"},
+		{p1, `{"type": "open_vote"}`, "forbidden"},
+		{hostWS, `{"type": "cast_vote", "suspect": "P2"}`, "forbidden"},
+		{p1, `{"type": "cast_vote", "suspect": "P2"}`, "rejected"}, // no vote open
+	}
+	for _, c := range cases {
+		c.ws.WriteMessage(websocket.TextMessage, []byte(c.msg))
+		msg := readUntil(t, c.ws, "error")
+		if msg["reason"] != c.reason || msg["error"] == "" || msg["v"] != 1.0 {
+			t.Fatalf("sending %s: expected %s error, got %v", c.msg, c.reason, msg)
+		}
+	}
+
+	// a client asking for a newer version is answered in the newest we speak,
+	// and the first message has to be a join
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/" + code
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatal("failed to connect:", err)
+	}
+	defer ws.Close()
+	ws.WriteJSON(map[string]interface{}{"type": "vote_bad", "voted": true})
+	if msg := readUntil(t, ws, "error"); msg["reason"] != "join_required" {
+		t.Fatalf("expected join_required, got %v", msg)
+	}
+
+	ws, _, err = websocket.DefaultDialer.Dial(wsURL+"?name=P4&v=99", nil)
+	if err != nil {
+		t.Fatal("failed to connect:", err)
+	}
+	defer ws.Close()
+	if msg := readUntil(t, ws, "lobby_state"); msg["v"] != 1.0 {
+		t.Fatalf("expected to negotiate version 1, got %v", msg)
+	}
+
+	t.Log("✓ Protocol errors reported with a reason")
+}
+
+// TestSlowClientDisconnected tests that a client that stops reading is dropped
+// instead of blocking whoever sends to it
+func TestSlowClientDisconnected(t *testing.T) {
+	clients := make(chan *client, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error("upgrade failed:", err)
+			return
+		}
+		clients <- newClient(conn, defaultHeartbeat)
+	}))
+	defer server.Close()
+
+	// connect but never read
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal("failed to connect:", err)
+	}
+	defer ws.Close()
+	c := <-clients
+
+	players := make([]string, 4096)
+	for i := range players {
+		players[i] = "player-" + strconv.Itoa(i)
+	}
+	msg := &protocol.LobbyState{Code: "slow", Players: players}
+
+	start := time.Now()
+	var sendErr error
+	for i := 0; i < 10000 && sendErr == nil; i++ {
+		sendErr = c.send(msg)
+	}
+	if sendErr != errSlowClient {
+		t.Fatalf("expected the client to be dropped as too slow, got %v", sendErr)
+	}
+	if elapsed := time.Since(start); elapsed > writeWait/2 {
+		t.Fatalf("expected sending to never block, took %v", elapsed)
+	}
+	select {
+	case <-c.done:
+	default:
+		t.Fatal("expected the slow client to be closed")
+	}
+	if err := c.send(msg); err != errClientClosed {
+		t.Fatalf("expected sends after close to fail, got %v", err)
+	}
+
+	t.Log("✓ Slow client disconnected without blocking the sender")
+}
+
+// TestHeartbeat tests that unresponsive sockets are detected and reported
+func TestHeartbeat(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	lm.heartbeat = heartbeat{interval: 20 * time.Millisecond, timeout: 100 * time.Millisecond}
+	lm.reconnectGrace = 150 * time.Millisecond
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/" + createResp.Code
+
+	hostWS, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=Host&host_token="+createResp.HostToken, nil)
+	if err != nil {
+		t.Fatal("host failed to connect:", err)
+	}
+	defer hostWS.Close()
+	readUntil(t, hostWS, "host_ready")
+
+	// P1 keeps reading, so it answers pings; P2 goes quiet like a locked phone
+	p1, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=P1", nil)
+	if err != nil {
+		t.Fatal("P1 failed to connect:", err)
+	}
+	defer p1.Close()
+	go func() {
+		for {
+			if _, _, err := p1.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	p2, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=P2", nil)
+	if err != nil {
+		t.Fatal("P2 failed to connect:", err)
+	}
+	defer p2.Close()
+
+	for _, status := range []string{"away", "gone"} {
+		msg := readUntil(t, hostWS, "player_status")
+		if msg["name"] != "P2" || msg["status"] != status {
+			t.Fatalf("expected P2 %s, got %v", status, msg)
+		}
+	}
+	state := readUntil(t, hostWS, "lobby_state")
+	if players := state["players"].([]interface{}); len(players) != 1 || players[0] != "P1" {
+		t.Fatalf("expected only P1 left, got %v", state)
+	}
+
+	t.Log("✓ Dead connection detected by heartbeat")
+}
+
+// TestMemoryStore tests the in-memory lobby store
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	old := &Lobby{Code: "bbbb", CreatedAt: time.Now().Add(-time.Hour)}
+	fresh := &Lobby{Code: "aaaa", CreatedAt: time.Now()}
+	s.Put(old)
+	s.Put(fresh)
+
+	if l, ok := s.Get("bbbb"); !ok || l != old {
+		t.Fatal("expected to get the stored lobby")
+	}
+	if list := s.List(); len(list) != 2 || list[0] != fresh {
+		t.Fatalf("expected lobbies ordered by code, got %v", list)
+	}
+
+	expired, err := s.Expire(func(l *Lobby) bool { return time.Since(l.CreatedAt) > 15*time.Minute })
+	if err != nil || len(expired) != 1 || expired[0] != old {
+		t.Fatalf("expected only the old lobby to expire, got %v %v", expired, err)
+	}
+	if _, ok := s.Get("bbbb"); ok {
+		t.Fatal("expected expired lobby to be removed")
+	}
+
+	s.Delete("aaaa")
+	if len(s.List()) != 0 {
+		t.Fatal("expected store to be empty")
+	}
+
+	t.Log("✓ Memory store gets, lists, expires and deletes lobbies")
+}
+
+// TestFileStoreRestart tests that a running game survives a server restart
+func TestFileStoreRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lobbies.journal")
+	fs, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal("failed to open journal:", err)
+	}
+	router := newTestRouter(newLobbyManager(testConfig(), fs))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	code, _, _, started := startTestGame(t, router, server, []string{"P1", "P2", "P3"}, `{"imposters": 1}`)
+
+	l, _ := fs.Get(code)
+	l.mu.Lock()
+	token, hostToken := l.tokenFor("P1"), l.HostToken
+	l.mu.Unlock()
+	if err := fs.Close(); err != nil {
+		t.Fatal("failed to close journal:", err)
+	}
+
+	// a crash mid-write leaves a partial line behind
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString(`{"op":"put","code":"zz`)
+	f.Close()
+
+	fs2, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal("failed to reopen journal:", err)
+	}
+	defer fs2.Close()
+	router2 := newTestRouter(newLobbyManager(testConfig(), fs2))
+	server2 := httptest.NewServer(router2)
+	defer server2.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server2.URL, "http") + "/api/v1/ws/" + code
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?token="+token, nil)
+	if err != nil {
+		t.Fatal("failed to reconnect after restart:", err)
+	}
+	defer ws.Close()
+
+	after := readUntil(t, ws, "game_started")
+	before := started["P1"]
+	if after["role"] != before["role"] || after["word"] != before["word"] {
+		t.Fatalf("expected same role and word after restart, got %v then %v", before, after)
+	}
+	state := readUntil(t, ws, "lobby_state")
+	if len(state["players"].([]interface{})) != 3 {
+		t.Fatalf("expected all seats to be held after restart, got %v", state)
+	}
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies/"+code+"/end", nil)
+	req.Header.Set("X-Host-Token", hostToken)
+	w := httptest.NewRecorder()
+	router2.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected host token to survive restart, got %d", w.Code)
+	}
+
+	t.Log("✓ Lobby, roles and word restored from the journal after restart")
+}
+
+// TestExpiredLobbyStaysGone tests that sockets closing on an expired lobby
+// don't write it back to the store
+func TestExpiredLobbyStaysGone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lobbies.journal")
+	fs, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal("failed to open journal:", err)
+	}
+	lm := newLobbyManager(testConfig(), fs)
+	lm.reconnectGrace = 20 * time.Millisecond
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	code, hostWS, playerWSs, _ := startTestGame(t, router, server, []string{"P1", "P2"}, `{"imposters": 1}`)
+	l, _ := fs.Get(code)
+	l.mu.Lock()
+	l.LastActive = time.Now().Add(-time.Hour)
+	l.mu.Unlock()
+
+	lm.cleanupExpiredLobbies()
+	for _, ws := range append([]*websocket.Conn{hostWS}, playerWSs["P1"], playerWSs["P2"]) {
+		readUntil(t, ws, "lobby_closed")
+	}
+	// long enough for every seat to be released had one been held
+	time.Sleep(150 * time.Millisecond)
+	if _, ok := fs.Get(code); ok {
+		t.Fatal("expected the expired lobby to stay out of the store")
+	}
+	if err := fs.Close(); err != nil {
+		t.Fatal("failed to close journal:", err)
+	}
+
+	fs2, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal("failed to reopen journal:", err)
+	}
+	defer fs2.Close()
+	if _, ok := fs2.Get(code); ok {
+		t.Fatal("expected the expired lobby not to be restored")
+	}
+
+	t.Log("✓ Expired lobby not saved back as its sockets closed")
+}
+
+// TestShutdown tests that shutting down warns clients and closes their sockets
+func TestShutdown(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	code, hostWS, playerWSs, _ := startTestGame(t, router, server, []string{"P1", "P2", "P3"}, `{"imposters": 1}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := lm.Shutdown(ctx, 30*time.Second); err != nil {
+		t.Fatal("shutdown failed:", err)
+	}
+
+	for _, ws := range append([]*websocket.Conn{hostWS}, playerWSs["P1"], playerWSs["P2"], playerWSs["P3"]) {
+		msg := readUntil(t, ws, "server_restarting")
+		if msg["code"] != code || msg["eta"] != float64(30) {
+			t.Fatalf("expected restart notice with eta 30, got %v", msg)
+		}
+		_, _, err := ws.ReadMessage()
+		if !websocket.IsCloseError(err, websocket.CloseServiceRestart) {
+			t.Fatalf("expected close code %d, got %v", websocket.CloseServiceRestart, err)
+		}
+	}
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected new lobbies to be refused, got %d", w.Code)
+	}
+
+	// seats are kept for players to resume after the restart
+	l, _ := lm.store.Get(code)
+	l.mu.Lock()
+	players := len(l.Players)
+	l.mu.Unlock()
+	if players != 3 {
+		t.Fatalf("expected seats to be kept, got %d players", players)
+	}
+
+	t.Log("✓ Shutdown warned clients, closed sockets and kept seats")
+}
+
+// TestConfig tests that settings come from flags, then the environment, then
+// the config file, and are validated
+func TestConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "imposter.conf")
+	os.WriteFile(path, []byte("# test settings\ncode-length = 5\nlobby-expiry = 20m\nlog-path =\naddr = :9000\n"), 0644)
+	env := map[string]string{
+		"IMPOSTER_CONFIG":          path,
+		"IMPOSTER_LOBBY_EXPIRY":    "30m",
+		"IMPOSTER_ALLOWED_ORIGINS": "https://imposter.example, http://localhost:*",
+	}
+
+	cfg := DefaultConfig()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.RegisterFlags(fs)
+	if err := fs.Parse([]string{"-addr", ":9001"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Load(fs, func(k string) string { return env[k] }); err != nil {
+		t.Fatal("failed to load config:", err)
+	}
+	if cfg.Addr != ":9001" || cfg.LobbyExpiry != 30*time.Minute || cfg.CodeLength != 5 || cfg.LogPath != "" {
+		t.Fatalf("expected flag, then env, then file to win, got %+v", cfg)
+	}
+	if len(cfg.AllowedOrigins) != 2 || cfg.CleanupInterval != time.Minute {
+		t.Fatalf("expected parsed origins and default cleanup interval, got %+v", cfg)
+	}
+	if !originAllowed("http://localhost:3000", cfg.AllowedOrigins) || originAllowed("https://evil.example", cfg.AllowedOrigins) {
+		t.Fatal("expected origins to be matched against the patterns")
+	}
+
+	var out bytes.Buffer
+	cfg.Print(&out)
+	if !strings.Contains(out.String(), "lobby-expiry = 30m0s\n") || strings.Contains(out.String(), "config =") {
+		t.Fatalf("unexpected printed config:\n%s", out.String())
+	}
+
+	bad := DefaultConfig()
+	bad.CodeLength = 1
+	if bad.Validate() == nil {
+		t.Fatal("expected a one-letter code length to be rejected")
+	}
+	os.WriteFile(path, []byte("lobby-expirey = 5m\n"), 0644)
+	cfg = DefaultConfig()
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.RegisterFlags(fs)
+	fs.Parse(nil)
+	if err := cfg.Load(fs, func(k string) string { return env[k] }); err == nil || !strings.Contains(err.Error(), "unknown setting") {
+		t.Fatalf("expected unknown setting error, got %v", err)
+	}
+
+	t.Log("✓ Config loaded from flags, environment and file, and validated")
+}
+
+// TestSlidingExpiry tests that idle lobbies are warned, can be extended by
+// the host and are closed with a reason
+func TestSlidingExpiry(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var createResp createLobbyResp
+	json.NewDecoder(w.Body).Decode(&createResp)
+	code := createResp.Code
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws/" + code
+
+	hostWS, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=Host&host_token="+createResp.HostToken, nil)
+	if err != nil {
+		t.Fatal("host failed to connect:", err)
+	}
+	defer hostWS.Close()
+	readUntil(t, hostWS, "host_ready")
+	playerWS, _, err := websocket.DefaultDialer.Dial(wsURL+"?name=P1", nil)
+	if err != nil {
+		t.Fatal("player failed to connect:", err)
+	}
+	defer playerWS.Close()
+	readUntil(t, playerWS, "lobby_state")
+
+	l, _ := lm.store.Get(code)
+	idle := func(d time.Duration) {
+		l.mu.Lock()
+		l.CreatedAt = time.Now().Add(-time.Hour)
+		l.LastActive = time.Now().Add(-d)
+		l.mu.Unlock()
+	}
+
+	// an hour-old lobby that was active recently stays open
+	idle(time.Minute)
+	lm.cleanupExpiredLobbies()
+	if _, ok := lm.store.Get(code); !ok {
+		t.Fatal("expected an active lobby to stay open")
+	}
+
+	idle(14 * time.Minute)
+	lm.cleanupExpiredLobbies()
+	for _, ws := range []*websocket.Conn{hostWS, playerWS} {
+		msg := readUntil(t, ws, "lobby_expiring")
+		if msg["expires_in"].(float64) > 60 {
+			t.Fatalf("expected lobby to expire within a minute, got %v", msg)
+		}
+	}
+
+	hostWS.WriteJSON(map[string]string{"type": "extend"})
+	msg := readUntil(t, playerWS, "lobby_extended")
+	if msg["expires_in"].(float64) < 890 {
+		t.Fatalf("expected a full expiry period after extending, got %v", msg)
+	}
+
+	idle(16 * time.Minute)
+	lm.cleanupExpiredLobbies()
+	for _, ws := range []*websocket.Conn{hostWS, playerWS} {
+		if msg := readUntil(t, ws, "lobby_closed"); msg["reason"] != ClosedExpired {
+			t.Fatalf("expected lobby closed as expired, got %v", msg)
+		}
+		if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			t.Fatalf("expected socket to close, got %v", err)
+		}
+	}
+	if _, ok := lm.store.Get(code); ok {
+		t.Fatal("expected expired lobby to be removed")
+	}
+
+	t.Log("✓ Idle lobby warned, extended by host and closed with a reason")
+}
+
+// TestEventLog tests that lobby events are written as JSON lines with the
+// word and roles redacted
+func TestEventLog(t *testing.T) {
+	cfg := testConfig()
+	cfg.LogPath = filepath.Join(t.TempDir(), "events.log")
+	lm := NewLobbyManager(cfg)
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	code, _, _, started := startTestGame(t, router, server, []string{"P1", "P2", "P3"}, `{"imposters": 1}`)
+
+	data, err := os.ReadFile(cfg.LogPath)
+	if err != nil {
+		t.Fatal("failed to read event log:", err)
+	}
+	events := map[string][]map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("expected JSON lines, got %q", line)
+		}
+		events[e["event"].(string)] = append(events[e["event"].(string)], e)
+	}
+	if len(events[EventLobbyCreated]) != 1 || len(events[EventPlayerJoined]) != 3 {
+		t.Fatalf("expected lobby_created and three player_joined events, got %v", events)
+	}
+	game := events[EventGameStarted][0]
+	if game["code"] != code || game["players"] != 3.0 || game["imposters"] != 1.0 {
+		t.Fatalf("expected game_started with counts, got %v", game)
+	}
+	if game["word"] != redacted || game["roles"] != redacted {
+		t.Fatalf("expected word and roles to be redacted, got %v", game)
+	}
+	id, _ := game["word_id"].(string)
+	if id == "" || id == redacted {
+		t.Fatalf("expected a word id that survives redaction, got %v", game)
+	}
+	// the pack is public, so the id must not be something anyone could work
+	// out from its words
+	l, _ := lm.store.Get(code)
+	l.mu.Lock()
+	pack, word := l.GamePack, l.GameWord
+	l.mu.Unlock()
+	if id != lm.wordID(pack, word) {
+		t.Fatalf("expected the id of %q, got %q", word, id)
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(word)))
+	other := &LobbyManager{wordKey: newWordKey()}
+	if strings.HasSuffix(id, hex.EncodeToString(sum[:4])) || id == other.wordID(pack, word) {
+		t.Fatalf("word id %q can be matched back to the word", id)
+	}
+	for _, msg := range started {
+		if word, _ := msg["word"].(string); word != "" && strings.Contains(string(data), word) {
+			t.Fatalf("word %q leaked into the event log", word)
+		}
+	}
+
+	t.Log("✓ Events logged as JSON lines with secrets redacted")
+}
+
+// TestRotatingFile tests that the log file rotates by size and keeps only
+// the newest backups
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	f, err := openRotatingFile(path, 10, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for i := 0; i < 4; i++ {
+		f.Write([]byte("12345678\n"))
+		time.Sleep(2 * time.Millisecond) // distinct backup names
+	}
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups to be kept, got %v", backups)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "12345678\n" {
+		t.Fatalf("expected only the latest line in the current file, got %q", data)
+	}
+
+	t.Log("✓ Log file rotated by size with old backups pruned")
+}
+
+// TestPhaseTimers tests that round phases advance on the server's clock, that
+// the host can pause, resume and skip them, and that ending or restarting the
+// game cancels the clock
+func TestPhaseTimers(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	code, hostWS, playerWSs, _ := startTestGame(t, router, server, []string{"P1", "P2", "P3"},
+		`{"imposters": 1, "tie_rule": "none", "timers": {"clue": 1, "discussion": 1}}`)
+	p1 := playerWSs["P1"]
+	l, _ := lm.store.Get(code)
+
+	msg := readUntil(t, hostWS, "phase_changed")
+	if msg["phase"] != PhaseClue || msg["duration"] != float64(1) || msg["ends_at"] == nil {
+		t.Fatalf("expected a one second clue phase, got %v", msg)
+	}
+	if at, err := time.Parse(time.RFC3339Nano, msg["ends_at"].(string)); err != nil || time.Until(at) > time.Second {
+		t.Fatalf("expected an absolute deadline within a second, got %v", msg["ends_at"])
+	}
+
+	// the clue phase runs out by itself
+	if msg = readUntil(t, p1, "phase_changed"); msg["phase"] != PhaseClue {
+		t.Fatalf("expected the clue phase first, got %v", msg)
+	}
+	if msg = readUntil(t, p1, "phase_changed"); msg["phase"] != PhaseDiscussion {
+		t.Fatalf("expected discussion after the clue timer, got %v", msg)
+	}
+
+	// a paused phase keeps its time left
+	hostWS.WriteJSON(map[string]string{"type": "pause_phase"})
+	msg = readUntil(t, p1, "phase_changed")
+	if msg["paused"] != true || msg["ends_at"] != nil || msg["phase"] != PhaseDiscussion {
+		t.Fatalf("expected discussion to be paused, got %v", msg)
+	}
+	time.Sleep(1200 * time.Millisecond)
+	l.mu.Lock()
+	phase := l.phase.name
+	l.mu.Unlock()
+	if phase != PhaseDiscussion {
+		t.Fatalf("expected the paused phase to stay put, got %s", phase)
+	}
+
+	// players cannot drive the clock
+	p1.WriteJSON(map[string]string{"type": "resume_phase"})
+	if msg = readUntil(t, p1, "error"); msg["reason"] != protocol.ReasonForbidden {
+		t.Fatalf("expected a forbidden error, got %v", msg)
+	}
+
+	hostWS.WriteJSON(map[string]string{"type": "resume_phase"})
+	if msg = readUntil(t, p1, "phase_changed"); msg["paused"] != false || msg["ends_at"] == nil {
+		t.Fatalf("expected discussion to resume, got %v", msg)
+	}
+
+	// the vote opens when discussion runs out, and voting has no time limit
+	readUntil(t, p1, "vote_opened")
+	msg = readUntil(t, p1, "phase_changed")
+	if msg["phase"] != PhaseVoting || msg["ends_at"] != nil {
+		t.Fatalf("expected an open-ended voting phase, got %v", msg)
+	}
+	hostWS.WriteJSON(map[string]string{"type": "pause_phase"})
+	readUntil(t, hostWS, "error")
+
+	// skipping voting tallies it; with no votes nobody is out and the game goes on
+	hostWS.WriteJSON(map[string]string{"type": "skip_phase"})
+	if msg = readUntil(t, p1, "vote_result"); msg["eliminated"] != "" {
+		t.Fatalf("expected nobody to be voted out, got %v", msg)
+	}
+	if msg = readUntil(t, p1, "phase_changed"); msg["phase"] != PhaseReveal {
+		t.Fatalf("expected the reveal, got %v", msg)
+	}
+	hostWS.WriteJSON(map[string]string{"type": "skip_phase"})
+	if msg = readUntil(t, p1, "phase_changed"); msg["phase"] != PhaseClue {
+		t.Fatalf("expected another round of clues, got %v", msg)
+	}
+
+	// restarting starts a fresh clock and ending the game stops it
+	for _, action := range []string{"restart", "end"} {
+		req, _ := http.NewRequest("POST", "/api/v1/lobbies/"+code+"/"+action, nil)
+		req.Header.Set("X-Host-Token", l.HostToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s failed: %d", action, w.Code)
+		}
+	}
+	l.mu.Lock()
+	stopped := l.phase == nil
+	l.mu.Unlock()
+	if !stopped {
+		t.Fatal("expected ending the game to stop the phase clock")
+	}
+	readUntil(t, p1, "game_ended")
+	_ = p1.SetReadDeadline(time.Now().Add(1500 * time.Millisecond))
+	for {
+		var m map[string]interface{}
+		if err := p1.ReadJSON(&m); err != nil {
+			break
+		}
+		if m["type"] == "phase_changed" {
+			t.Fatalf("expected no phase changes after the game ended, got %v", m)
+		}
+	}
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies/"+code+"/start", bytes.NewBufferString(`{"imposters": 1, "timers": {"clue": -1}}`))
+	req.Header.Set("X-Host-Token", l.HostToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected negative timers to be rejected, got %d", w.Code)
+	}
+
+	t.Log("✓ Phases advanced on the clock, paused, resumed, skipped and stopped")
+}
+
+// TestSpeakingOrder tests that each round deals a speaking order following
+// the lobby's turn rules, and that only the speaker or host passes the turn
+func TestSpeakingOrder(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	names := []string{"P1", "P2", "P3", "P4"}
+	code, hostWS, playerWSs, _ := startTestGame(t, router, server, names,
+		`{"imposters": 1, "turns": {"no_imposter_first": true, "no_repeat_first": true}}`)
+	l, _ := lm.store.Get(code)
+
+	prevFirst := ""
+	for i := 0; i < 10; i++ {
+		if i > 0 {
+			req, _ := http.NewRequest("POST", "/api/v1/lobbies/"+code+"/restart", nil)
+			req.Header.Set("X-Host-Token", l.HostToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("restart failed: %d", w.Code)
+			}
+		}
+		msg := readUntil(t, hostWS, "speaking_order")
+		order := msg["order"].([]interface{})
+		if len(order) != len(names) || msg["turn"] != float64(0) {
+			t.Fatalf("expected every player in the order, starting at turn 0, got %v", msg)
+		}
+		first := order[0].(string)
+		l.mu.Lock()
+		role := l.PlayerRole[first]
+		l.mu.Unlock()
+		if role == "imposter" {
+			t.Fatalf("expected a word player to speak first, got imposter %s", first)
+		}
+		if first == prevFirst {
+			t.Fatalf("expected a new first speaker, got %s twice", first)
+		}
+		prevFirst = first
+	}
+
+	l.mu.Lock()
+	order := append([]string{}, l.SpeakingOrder...)
+	l.mu.Unlock()
+	speaker := order[0]
+	for _, name := range names {
+		if name != speaker {
+			playerWSs[name].WriteJSON(map[string]string{"type": "next_turn"})
+			if msg := readUntil(t, playerWSs[name], "error"); msg["error"] != "it is not your turn" {
+				t.Fatalf("expected %s to be refused the turn, got %v", name, msg)
+			}
+			break
+		}
+	}
+
+	// the speaker passes the turn, then the host moves everyone else along
+	playerWSs[speaker].WriteJSON(map[string]string{"type": "next_turn"})
+	for turn := 1; turn <= len(order); turn++ {
+		msg := readUntil(t, playerWSs["P1"], "turn_changed")
+		want := ""
+		if turn < len(order) {
+			want = order[turn]
+		}
+		if msg["speaker"] != want || msg["turn"] != float64(turn) {
+			t.Fatalf("expected turn %d to go to %q, got %v", turn, want, msg)
+		}
+		if turn < len(order) {
+			hostWS.WriteJSON(map[string]string{"type": "next_turn"})
+		}
+	}
+
+	// once everyone has spoken the clues are over
+	if msg := readUntil(t, playerWSs["P1"], "phase_changed"); msg["phase"] != PhaseDiscussion {
+		t.Fatalf("expected discussion after the last clue, got %v", msg)
+	}
+	hostWS.WriteJSON(map[string]string{"type": "next_turn"})
+	if msg := readUntil(t, hostWS, "error"); msg["error"] != "everyone has spoken" {
+		t.Fatalf("expected no turns left, got %v", msg)
+	}
+
+	t.Log("✓ Speaking order followed the turn rules and turns passed in order")
+}
+
+// TestClueBoard tests that clues are taken in speaking order, one per turn,
+// that a player cannot give their own word, and that clues are kept in the
+// round history
+func TestClueBoard(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	names := []string{"P1", "P2", "P3"}
+	code, hostWS, playerWSs, started := startTestGame(t, router, server, names,
+		`{"imposters": 1, "turns": {"no_imposter_first": true}}`)
+	l, _ := lm.store.Get(code)
+	l.mu.Lock()
+	order := append([]string{}, l.SpeakingOrder...)
+	l.mu.Unlock()
+	first := order[0]
+
+	playerWSs[order[1]].WriteJSON(map[string]string{"type": "submit_clue", "clue": "early"})
+	if msg := readUntil(t, playerWSs[order[1]], "error"); msg["error"] != "it is not your turn" {
+		t.Fatalf("expected an out of turn clue to be refused, got %v", msg)
+	}
+	hostWS.WriteJSON(map[string]string{"type": "submit_clue", "clue": "host"})
+	if msg := readUntil(t, hostWS, "error"); msg["reason"] != protocol.ReasonForbidden {
+		t.Fatalf("expected the host to be refused, got %v", msg)
+	}
+
+	// the first speaker knows the word and cannot just say it, even in the plural
+	word := started[first]["word"].(string)
+	for _, clue := range []string{" " + strings.ToUpper(word), plural(word)} {
+		playerWSs[first].WriteJSON(map[string]string{"type": "submit_clue", "clue": clue})
+		if msg := readUntil(t, playerWSs[first], "error"); msg["error"] != "you cannot give the word itself" {
+			t.Fatalf("expected %q to be refused as a clue, got %v", clue, msg)
+		}
+	}
+
+	clues := []string{"round", "sweet", "crunchy"}
+	for i, name := range order {
+		playerWSs[name].WriteJSON(map[string]string{"type": "submit_clue", "clue": clues[i]})
+		board := readUntil(t, hostWS, "clue_board")
+		got := board["clues"].([]interface{})
+		if len(got) != i+1 || board["pass"] != float64(1) {
+			t.Fatalf("expected %d clues on the board, got %v", i+1, board)
+		}
+		last := got[i].(map[string]interface{})
+		if last["name"] != name || last["clue"] != clues[i] {
+			t.Fatalf("expected %s's clue %q, got %v", name, clues[i], last)
+		}
+		if i == 0 {
+			// one clue per turn
+			playerWSs[name].WriteJSON(map[string]string{"type": "submit_clue", "clue": "again"})
+			if msg := readUntil(t, playerWSs[name], "error"); msg["error"] != "it is not your turn" {
+				t.Fatalf("expected a second clue to be refused, got %v", msg)
+			}
+		}
+	}
+	if msg := readUntil(t, hostWS, "phase_changed"); msg["phase"] != PhaseDiscussion {
+		t.Fatalf("expected discussion once everyone gave a clue, got %v", msg)
+	}
+
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies/"+code+"/end", nil)
+	req.Header.Set("X-Host-Token", l.HostToken)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/api/v1/lobbies/"+code+"/scoreboard", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var sb protocol.ScoreboardData
+	json.NewDecoder(w.Body).Decode(&sb)
+	if len(sb.Rounds) != 1 || len(sb.Rounds[0].Clues) != 3 || sb.Rounds[0].Clues[2].Clue != "crunchy" {
+		t.Fatalf("expected the clues in the round history, got %+v", sb.Rounds)
+	}
+
+	t.Log("✓ Clues taken in turn, the word refused and clues kept in history")
+}
+
+func TestBadWordReplaced(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	names := []string{"P1", "P2", "P3", "P4"}
+	code, hostWS, playerWSs, started := startTestGame(t, router, server, names, `{"imposters": 1}`)
+	l, _ := lm.store.Get(code)
+
+	var wordPlayers []string
+	for _, name := range names {
+		if started[name]["role"] == "word" {
+			wordPlayers = append(wordPlayers, name)
+		}
+	}
+	l.mu.Lock()
+	old, pack := l.GameWord, l.GamePack
+	l.mu.Unlock()
+
+	// one of three word players is not a majority
+	playerWSs[wordPlayers[0]].WriteJSON(map[string]interface{}{"type": "vote_bad", "voted": true})
+	if msg := readUntil(t, hostWS, "word_vote_update"); msg["count"] != float64(1) {
+		t.Fatalf("expected one bad word vote, got %v", msg)
+	}
+	playerWSs[wordPlayers[1]].WriteJSON(map[string]interface{}{"type": "vote_bad", "voted": true})
+	if msg := readUntil(t, hostWS, "word_vote_update"); msg["count"] != float64(2) {
+		t.Fatalf("expected two bad word votes, got %v", msg)
+	}
+	if msg := readUntil(t, hostWS, "word_vote_update"); msg["count"] != float64(0) {
+		t.Fatalf("expected the votes reset once the word was replaced, got %v", msg)
+	}
+
+	msg := readUntil(t, playerWSs[wordPlayers[2]], "game_started")
+	l.mu.Lock()
+	word := l.GameWord
+	if msg["word"] != word || strings.EqualFold(word, old) || l.GamePack != pack {
+		t.Fatalf("expected a new word from pack %s, got %v (was %s)", pack, msg, old)
+	}
+	if len(l.RejectedWords) != 1 || l.RejectedWords[0] != old || len(l.PlayerWordVotedBad) != 0 {
+		t.Fatalf("expected %s rejected and the votes cleared, got %v %v", old, l.RejectedWords, l.PlayerWordVotedBad)
+	}
+	l.PlayerWordVotedBad[wordPlayers[0]] = true
+	l.mu.Unlock()
+
+	// a restart clears the votes and never deals the rejected word again
+	for range 20 {
+		req, _ := http.NewRequest("POST", "/api/v1/lobbies/"+code+"/restart", nil)
+		req.Header.Set("X-Host-Token", l.HostToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("restart failed: %d %s", w.Code, w.Body.String())
+		}
+		l.mu.Lock()
+		if strings.EqualFold(l.GameWord, old) || len(l.PlayerWordVotedBad) != 0 {
+			t.Fatalf("expected a fresh word and no votes after restart, got %s %v", l.GameWord, l.PlayerWordVotedBad)
+		}
+		l.mu.Unlock()
+	}
+
+	t.Log("✓ Word replaced on a majority of bad votes and never dealt again")
+}
+
+// TestBadWordReplacedUndercover tests that replacing the word in undercover
+// mode deals everyone again, so imposters can't tell they were left out
+func TestBadWordReplacedUndercover(t *testing.T) {
+	lm := NewLobbyManager(testConfig())
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	names := []string{"P1", "P2", "P3", "P4"}
+	code, hostWS, playerWSs, _ := startTestGame(t, router, server, names,
+		`{"imposters": 1, "mode": "undercover", "packs": ["animals"]}`)
+	l, _ := lm.store.Get(code)
+
+	// deal from a group with a word to spare
+	groups, _ := lm.packs.GroupPool([]string{"animals"})
+	i := slices.IndexFunc(groups, func(g WordGroup) bool { return len(g.Words) >= 3 })
+	var imposter string
+	var wordPlayers []string
+	l.mu.Lock()
+	l.GameWord, l.DecoyWord = groups[i].Words[0], groups[i].Words[1]
+	for _, name := range names {
+		if l.PlayerRole[name] == "imposter" {
+			imposter = name
+		} else {
+			wordPlayers = append(wordPlayers, name)
+		}
+	}
+	old, decoy := l.GameWord, l.DecoyWord
+	l.mu.Unlock()
+
+	// the imposter may vote too, but only word players count
+	for n, name := range []string{imposter, wordPlayers[0], wordPlayers[1]} {
+		playerWSs[name].WriteJSON(map[string]interface{}{"type": "vote_bad", "voted": true})
+		if msg := readUntil(t, hostWS, "word_vote_update"); msg["count"] != float64(n+1) {
+			t.Fatalf("expected %d bad word votes, got %v", n+1, msg)
+		}
+	}
+	if msg := readUntil(t, hostWS, "word_vote_update"); msg["count"] != float64(0) {
+		t.Fatalf("expected the votes reset once the word was replaced, got %v", msg)
+	}
+
+	l.mu.Lock()
+	word := l.GameWord
+	l.mu.Unlock()
+	if strings.EqualFold(word, old) || !slices.Contains(groups[i].Words, word) {
+		t.Fatalf("expected a new word from the decoy's group, got %s (was %s)", word, old)
+	}
+	for _, name := range names {
+		msg := readUntil(t, playerWSs[name], "game_started")
+		want := word
+		if name == imposter {
+			want = decoy
+		}
+		if msg["role"] != "word" || msg["word"] != want {
+			t.Fatalf("expected %s to be dealt %s again as a word player, got %v", name, want, msg)
+		}
+	}
+
+	t.Log("✓ Undercover word replaced with every player dealt again")
+}
+
+func TestWordHistory(t *testing.T) {
+	cfg := testConfig()
+	cfg.RecentWords = 2
+	lm := NewLobbyManager(cfg)
+	router := newTestRouter(lm)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	custom := []string{"Kite", "Heron", "Osprey"}
+	restart := func(l *Lobby) string {
+		t.Helper()
+		req, _ := http.NewRequest("POST", "/api/v1/lobbies/"+l.Code+"/restart", strings.NewReader(`{"custom_words": true}`))
+		req.Header.Set("X-Host-Token", l.HostToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("restart failed: %d %s", w.Code, w.Body.String())
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.GameWord
+	}
+	history := func(l *Lobby) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/lobbies/"+l.Code+"/history", nil)
+		req.Header.Set("X-Host-Token", l.HostToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	code, _, _, _ := startTestGame(t, router, server, []string{"P1", "P2", "P3"}, `{"imposters": 1}`)
+	l, _ := lm.store.Get(code)
+	l.mu.Lock()
+	l.CustomWords = custom
+	l.mu.Unlock()
+
+	// every custom word is dealt once before any comes back
+	seen := map[string]bool{}
+	var dealt []string
+	for range custom {
+		word := restart(l)
+		if seen[word] {
+			t.Fatalf("expected no repeats until the list runs out, got %v then %s", dealt, word)
+		}
+		seen[word] = true
+		dealt = append(dealt, word)
+	}
+	dealt = append(dealt, restart(l))
+
+	if w := history(l); w.Code != http.StatusConflict {
+		t.Fatalf("expected the history hidden while a game is on, got %d", w.Code)
+	}
+	req, _ := http.NewRequest("POST", "/api/v1/lobbies/"+code+"/end", nil)
+	req.Header.Set("X-Host-Token", l.HostToken)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	w := history(l)
+	var resp protocol.WordHistoryResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || len(resp.Words) != 5 || !slices.Equal(resp.Words[1:], dealt) {
+		t.Fatalf("expected the pack word and %v in the history, got %d %v", dealt, w.Code, resp.Words)
+	}
+
+	// another lobby avoids the words dealt most recently anywhere
+	code, _, _, _ = startTestGame(t, router, server, []string{"P1", "P2", "P3"}, `{"imposters": 1}`)
+	other, _ := lm.store.Get(code)
+	other.mu.Lock()
+	other.CustomWords = custom
+	other.mu.Unlock()
+	// the window holds the last custom word and the new lobby's pack word
+	if word := restart(other); strings.EqualFold(word, dealt[len(dealt)-1]) {
+		t.Fatalf("expected a word other than the recently dealt %s", word)
+	}
+
+	t.Log("✓ Words dealt without repeats, avoided across lobbies and listed for the host")
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+// Host authentication. CreateLobby hands the creator a secret host token,
+// which must accompany every lobby control request and the host WebSocket.
+// Browsers get it as a cookie too; other clients send it in the X-Host-Token
+// header (or as a bearer token). WebSockets may also pass it as ?host_token=
+// or in the join message, since browsers cannot set headers on them.
+
+const hostTokenHeader = "X-Host-Token"
+
+var (
+	errHostTokenRequired = errors.New("host token required")
+	errHostTokenInvalid  = errors.New("invalid host token")
+)
+
+func hostCookieName(code string) string {
+	return "imposter_host_" + code
+}
+
+// requestHostToken returns the host token sent with r, if any.
+func requestHostToken(r *http.Request, code string) string {
+	if tok := r.Header.Get(hostTokenHeader); tok != "" {
+		return tok
+	}
+	if tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return tok
+	}
+	if c, err := r.Cookie(hostCookieName(code)); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// checkHostToken reports whether token is this lobby's host token.
+// Callers must hold l.mu.
+func (l *Lobby) checkHostToken(token string) error {
+	if token == "" {
+		return errHostTokenRequired
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(l.HostToken)) != 1 {
+		return errHostTokenInvalid
+	}
+	return nil
+}
+
+// hostLobby looks up the lobby named in the URL and checks the request
+// carries its host token. On failure it writes the error response and
+// returns false.
+func (m *LobbyManager) hostLobby(w http.ResponseWriter, r *http.Request) (*Lobby, bool) {
+	code := chi.URLParam(r, "code")
+	l, ok := m.store.Get(code)
+	if !ok {
+		http.Error(w, "lobby not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	l.mu.Lock()
+	err := l.checkHostToken(requestHostToken(r, code))
+	if err == nil {
+		m.touch(l)
+	}
+	l.mu.Unlock()
+	switch err {
+	case nil:
+		return l, true
+	case errHostTokenRequired:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		m.logEvent(EventHostRejected, "code", code, "remote", r.RemoteAddr)
+		http.Error(w, err.Error(), http.StatusForbidden)
+	}
+	return nil, false
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	"imposter/api/protocol"
+)
+
+// Bad word votes. Players who think their word is unplayable vote it bad;
+// once enough of the word players agree, the word is swapped for another from
+// the same pack and is never dealt again in the lobby.
+
+// DefaultBadWordThreshold replaces the word once a majority of the word
+// players vote it bad.
+const DefaultBadWordThreshold = 51
+
+func validateBadWordThreshold(pct int) error {
+	if pct < 0 || pct > 100 {
+		return errors.New("bad_word_threshold must be 0 to 100")
+	}
+	return nil
+}
+
+// errWordsRejected is returned when every word the lobby could draw has been
+// voted out.
+var errWordsRejected = errors.New("every word in these packs has been rejected")
+
+// rejected reports whether word was voted out earlier. Callers must hold l.mu.
+func (l *Lobby) rejected(word string) bool {
+	return containsFold(l.RejectedWords, word)
+}
+
+// playableWords drops rejected words from pool. Callers must hold l.mu.
+func (l *Lobby) playableWords(pool []Word) []Word {
+	out := make([]Word, 0, len(pool))
+	for _, w := range pool {
+		if !l.rejected(w.Text) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// playableGroups drops rejected words from groups, and the groups left
+// without a pair to deal. Callers must hold l.mu.
+func (l *Lobby) playableGroups(groups []WordGroup) []WordGroup {
+	out := make([]WordGroup, 0, len(groups))
+	for _, g := range groups {
+		words := []string{}
+		for _, w := range g.Words {
+			if !l.rejected(w) {
+				words = append(words, w)
+			}
+		}
+		if len(words) >= 2 {
+			g.Words = words
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// wordVotes counts the word players and those of them voting the word bad.
+// Imposters may vote too, but don't count. Callers must hold l.mu.
+func (l *Lobby) wordVotes() (votes, players int) {
+	for _, p := range l.Players {
+		if l.PlayerRole[p] == "imposter" {
+			continue
+		}
+		players++
+		if l.PlayerWordVotedBad[p] {
+			votes++
+		}
+	}
+	return votes, players
+}
+
+// wordRejected reports whether enough word players have voted the word bad.
+// Callers must hold l.mu.
+func (l *Lobby) wordRejected() bool {
+	if l.BadWordThreshold == 0 || l.GameState != "started" {
+		return false
+	}
+	votes, players := l.wordVotes()
+	return votes > 0 && votes*100 >= l.BadWordThreshold*players
+}
+
+// replaceWord rejects the word and deals the word players another from the
+// same pack. Imposters keep what they have: in undercover mode the new word
+// comes from the decoy's group so the two stay related. The word stays if the
+// pack has nothing left to give. Callers must hold l.mu.
+func (m *LobbyManager) replaceWord(l *Lobby) {
+	old := l.GameWord
+	if !l.rejected(old) {
+		l.RejectedWords = append(l.RejectedWords, old)
+	}
+
+	word, ok := m.replacementWord(l)
+	if !ok {
+		m.save(l)
+		m.logEvent(EventWordReplaced, "code", l.Code, "word", old, "word_id", m.wordID(l.GamePack, old), "new_word", "")
+		return
+	}
+	l.GameWord, l.GameCategory = word.Text, word.Category
+	if l.round != nil {
+		l.round.Word = word.Text
+	}
+	l.PlayerWordVotedBad = make(map[string]bool)
+	m.save(l)
+	m.logEvent(EventWordReplaced, "code", l.Code, "word", old, "word_id", m.wordID(l.GamePack, old), "new_word", word.Text)
+
+	// imposters are sent what they already have: in undercover mode they
+	// mustn't be the only ones left out
+	for c := range l.clients {
+		_ = c.send(l.gameStartedMsg(c.name))
+	}
+	l.sendAll(&protocol.WordVoteUpdate{Code: l.Code, Count: 0})
+}
+
+// replacementWord picks the word to replace the current one with.
+// Callers must hold l.mu.
+func (m *LobbyManager) replacementWord(l *Lobby) (Word, bool) {
+	if l.Mode == ModeUndercover {
+		groups, _ := m.packs.GroupPool([]string{l.GamePack})
+		for _, g := range l.playableGroups(groups) {
+			if !containsFold(g.Words, l.DecoyWord) {
+				continue
+			}
+			words := []Word{}
+			for _, w := range g.Words {
+				if !strings.EqualFold(w, l.DecoyWord) {
+					words = append(words, Word{Text: w, Category: g.Category, Pack: g.Pack})
+				}
+			}
+			return m.drawWord(l, words), true
+		}
+		return Word{}, false
+	}
+
+	var pool []Word
+	if l.GamePack == "" {
+		pool, _ = m.wordPool(l.CustomWords, nil, true)
+	} else {
+		pool, _ = m.packs.Pool([]string{l.GamePack})
+	}
+	pool = l.playableWords(pool)
+	if len(pool) == 0 {
+		return Word{}, false
+	}
+	return m.drawWord(l, pool), true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, w := range list {
+		if strings.EqualFold(w, s) {
+			return true
+		}
+	}
+	return false
+}
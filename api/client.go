@@ -0,0 +1,164 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"imposter/api/protocol"
+)
+
+// client is one WebSocket connection to a lobby, from a player or the host.
+// Messages are queued and written by the client's own goroutine, so sending
+// never blocks: a client that falls too far behind is disconnected (it can
+// reconnect with its session token) rather than stalling the lobby.
+//
+// The writer also pings the client; a client that stops answering (say a
+// phone with its screen locked) hits its read deadline and is dropped.
+type client struct {
+	conn      *websocket.Conn
+	name      string
+	version   int // negotiated protocol version
+	heartbeat heartbeat
+
+	out       chan []byte
+	done      chan struct{} // closed to shut the client down
+	stopped   chan struct{} // closed once the connection is closed
+	closeOnce sync.Once
+	closeCode int // WebSocket close code sent when shutting down
+}
+
+const (
+	sendQueueSize = 64               // messages buffered per client
+	writeWait     = 10 * time.Second // time allowed to write one message
+)
+
+// heartbeat configures WebSocket keepalive pings.
+type heartbeat struct {
+	interval time.Duration // how often to ping
+	timeout  time.Duration // how long to wait for any message or pong
+}
+
+var defaultHeartbeat = heartbeat{
+	interval: 25 * time.Second,
+	timeout:  60 * time.Second,
+}
+
+var (
+	errClientClosed = errors.New("client closed")
+	errSlowClient   = errors.New("client too slow, disconnected")
+)
+
+// newClient wraps conn, arms its read deadline and starts its writer.
+// Must be called before anything reads from conn.
+func newClient(conn *websocket.Conn, hb heartbeat) *client {
+	c := &client{
+		conn:      conn,
+		version:   protocol.MinVersion,
+		heartbeat: hb,
+		out:       make(chan []byte, sendQueueSize),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		closeCode: websocket.CloseNormalClosure,
+	}
+	c.alive()
+	conn.SetPongHandler(func(string) error {
+		c.alive()
+		return nil
+	})
+	go c.writePump()
+	return c
+}
+
+// alive pushes the read deadline back after hearing from the client.
+// Only the reading goroutine may call it.
+func (c *client) alive() {
+	_ = c.conn.SetReadDeadline(time.Now().Add(c.heartbeat.timeout))
+}
+
+// read returns the next message from the client.
+func (c *client) read() ([]byte, error) {
+	_, data, err := c.conn.ReadMessage()
+	if err == nil {
+		c.alive()
+	}
+	return data, err
+}
+
+// send encodes msg for the client's protocol version and queues it.
+func (c *client) send(msg protocol.Message) error {
+	select {
+	case <-c.done:
+		return errClientClosed
+	default:
+	}
+	data, err := protocol.Encode(c.version, msg)
+	if err != nil {
+		return err
+	}
+	select {
+	case c.out <- data:
+		return nil
+	default:
+		c.close()
+		return errSlowClient
+	}
+}
+
+// close shuts the client down. Messages already queued are still written
+// before the connection is closed.
+func (c *client) close() {
+	c.closeWith(websocket.CloseNormalClosure)
+}
+
+// closeWith shuts the client down like close, ending the connection with
+// code. Only the first close of a client decides its code.
+func (c *client) closeWith(code int) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		close(c.done)
+	})
+}
+
+func (c *client) writePump() {
+	ping := time.NewTicker(c.heartbeat.interval)
+	defer ping.Stop()
+	defer close(c.stopped)
+	defer c.conn.Close()
+	for {
+		select {
+		case data := <-c.out:
+			if c.write(data) != nil {
+				c.close()
+				return
+			}
+		case <-ping.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+			if err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			for {
+				select {
+				case data := <-c.out:
+					if c.write(data) != nil {
+						return
+					}
+				default:
+					_ = c.conn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(c.closeCode, ""),
+						time.Now().Add(writeWait))
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *client) write(data []byte) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
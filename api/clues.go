@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+
+	"imposter/api/protocol"
+)
+
+// Clues. Each player types their clue when it is their turn to speak, so
+// remote players can follow along. Clues are kept in the round history.
+
+// Clue is part of the wire protocol.
+type Clue = protocol.Clue
+
+func (m *LobbyManager) submitClue(l *Lobby, name, clue string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.GameState != "started" || l.round == nil {
+		return errors.New("no round in progress")
+	}
+	if l.phase == nil || l.phase.name != PhaseClue {
+		return errors.New("it is not time for clues")
+	}
+	if l.speaker() != name {
+		return errors.New("it is not your turn")
+	}
+	clue = sanitizeWord(clue)
+	if clue == "" {
+		return errors.New("clue is empty or too long")
+	}
+	// Only the player's own word is off limits: refusing an imposter's clue
+	// for matching the real word would tell them what it is.
+	if own := l.ownWord(name); own != "" && wordsMatch(clue, own) {
+		return errors.New("you cannot give the word itself")
+	}
+
+	l.round.Clues = append(l.round.Clues, Clue{Name: name, Clue: clue, Pass: l.CluePass})
+	m.logEvent(EventClue, "code", l.Code, "player", name, "clue", clue)
+	l.sendAll(l.clueBoard())
+	m.passTurn(l)
+	return nil
+}
+
+// ownWord is the word name was dealt, "" for an imposter in classic mode.
+// Callers must hold l.mu.
+func (l *Lobby) ownWord(name string) string {
+	switch {
+	case l.PlayerRole[name] != "imposter":
+		return l.GameWord
+	case l.Mode == ModeUndercover:
+		return l.DecoyWord
+	}
+	return ""
+}
+
+// clueBoard builds the clue_board message for the current pass around the
+// table. Callers must hold l.mu.
+func (l *Lobby) clueBoard() *protocol.ClueBoard {
+	board := &protocol.ClueBoard{Code: l.Code, Pass: l.CluePass, Clues: []Clue{}}
+	if l.round == nil {
+		return board
+	}
+	for _, c := range l.round.Clues {
+		if c.Pass == l.CluePass {
+			board.Clues = append(board.Clues, c)
+		}
+	}
+	return board
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config holds the server settings. Every setting can be given as a flag
+// (-lobby-expiry), an environment variable (IMPOSTER_LOBBY_EXPIRY) or a line
+// in the config file (lobby-expiry = 15m); flags win over the environment,
+// which wins over the file.
+type Config struct {
+	Addr            string
+	LobbyExpiry     time.Duration // lobbies are removed after this long without activity
+	ExpiryWarning   time.Duration // how long before expiry clients are warned, 0 for never
+	CleanupInterval time.Duration // how often expired lobbies are removed
+	CodeLength      int           // letters in a lobby code
+	LogPath         string        // lobby event log, "" to disable
+	LogSink         string        // where events go: "file", "stdout" or "both"
+	LogMaxSizeMB    int           // rotate the log file at this size, 0 for no limit
+	LogMaxAge       time.Duration // rotate the log file at this age, 0 for no limit
+	LogMaxBackups   int           // rotated log files to keep, 0 for all
+	RedactSecrets   bool          // leave words and roles out of the event log
+	AllowedOrigins  []string      // CORS and WebSocket origins, "*" wildcards allowed
+	WordPacksDir    string        // extra word packs, "" for the built-in ones only
+	RecentWords     int           // words dealt in any lobby that new draws avoid, 0 to disable
+	LobbyJournal    string        // file to keep lobbies in across restarts, "" for memory only
+
+	ReconnectGrace    time.Duration // how long a dropped player's seat is held
+	HeartbeatInterval time.Duration // how often WebSocket clients are pinged
+	HeartbeatTimeout  time.Duration // how long a silent client is kept
+	ShutdownTimeout   time.Duration // how long to wait for connections to close
+	RestartETA        time.Duration // when clients are told to expect the server back
+
+	File string // config file the settings were read from, if any
+}
+
+// envPrefix is prepended to a flag's name, upper-cased, to get its
+// environment variable.
+const envPrefix = "IMPOSTER_"
+
+// DefaultConfig returns the settings used when nothing else is given.
+func DefaultConfig() Config {
+	return Config{
+		Addr:              "127.0.0.1:8080",
+		LobbyExpiry:       15 * time.Minute,
+		ExpiryWarning:     2 * time.Minute,
+		CleanupInterval:   time.Minute,
+		CodeLength:        4,
+		LogPath:           "lobbies.log",
+		LogSink:           SinkBoth,
+		LogMaxSizeMB:      50,
+		LogMaxAge:         7 * 24 * time.Hour,
+		LogMaxBackups:     10,
+		RedactSecrets:     true,
+		AllowedOrigins:    []string{"https://*", "http://*"},
+		ReconnectGrace:    defaultReconnectGrace,
+		HeartbeatInterval: defaultHeartbeat.interval,
+		HeartbeatTimeout:  defaultHeartbeat.timeout,
+		ShutdownTimeout:   10 * time.Second,
+		RestartETA:        30 * time.Second,
+	}
+}
+
+// RegisterFlags defines a flag for every setting on fs, defaulting to the
+// current values of c.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Addr, "addr", c.Addr, "address to listen on")
+	fs.DurationVar(&c.LobbyExpiry, "lobby-expiry", c.LobbyExpiry, "how long a lobby lasts without activity")
+	fs.DurationVar(&c.ExpiryWarning, "expiry-warning", c.ExpiryWarning, "how long before a lobby expires to warn its players, 0 for never")
+	fs.DurationVar(&c.CleanupInterval, "cleanup-interval", c.CleanupInterval, "how often expired lobbies are removed")
+	fs.IntVar(&c.CodeLength, "code-length", c.CodeLength, "letters in a lobby code")
+	fs.StringVar(&c.LogPath, "log-path", c.LogPath, "lobby event log file, empty to disable")
+	fs.StringVar(&c.LogSink, "log-sink", c.LogSink, "where lobby events are written: file, stdout or both")
+	fs.IntVar(&c.LogMaxSizeMB, "log-max-size", c.LogMaxSizeMB, "megabytes at which the log file is rotated, 0 for no limit")
+	fs.DurationVar(&c.LogMaxAge, "log-max-age", c.LogMaxAge, "age at which the log file is rotated, 0 for no limit")
+	fs.IntVar(&c.LogMaxBackups, "log-max-backups", c.LogMaxBackups, "rotated log files to keep, 0 for all")
+	fs.BoolVar(&c.RedactSecrets, "redact-secrets", c.RedactSecrets, "leave words, roles and guesses out of the event log")
+	fs.Var((*originList)(&c.AllowedOrigins), "allowed-origins", "comma-separated origins allowed to use the API, * matches anything")
+	fs.StringVar(&c.WordPacksDir, "wordpacks-dir", c.WordPacksDir, "directory of extra word packs, as .json or .yaml files")
+	fs.IntVar(&c.RecentWords, "recent-words", c.RecentWords, "words recently dealt in any lobby that new games avoid, 0 to disable")
+	fs.StringVar(&c.LobbyJournal, "lobby-journal", c.LobbyJournal, "file to keep lobbies in across restarts, empty for memory only")
+	fs.DurationVar(&c.ReconnectGrace, "reconnect-grace", c.ReconnectGrace, "how long a dropped player's seat is held")
+	fs.DurationVar(&c.HeartbeatInterval, "heartbeat-interval", c.HeartbeatInterval, "how often WebSocket clients are pinged")
+	fs.DurationVar(&c.HeartbeatTimeout, "heartbeat-timeout", c.HeartbeatTimeout, "how long a silent WebSocket client is kept")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "how long to wait for connections to close on shutdown")
+	fs.DurationVar(&c.RestartETA, "restart-eta", c.RestartETA, "when clients are told to expect the server back after a shutdown")
+	fs.StringVar(&c.File, "config", c.File, "config file of name = value lines")
+}
+
+// Load fills in the settings that were not set on the command line from the
+// environment and then the config file, and validates the result. fs must
+// have been parsed after c.RegisterFlags(fs).
+func (c *Config) Load(fs *flag.FlagSet, getenv func(string) string) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	if !explicit["config"] {
+		if v := getenv(envName("config")); v != "" {
+			c.File = v
+		}
+	}
+	settings := settingNames()
+	file := map[string]string{}
+	if c.File != "" {
+		var err error
+		if file, err = readConfigFile(c.File, settings); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if !settings[f.Name] || explicit[f.Name] || err != nil {
+			return
+		}
+		source := envName(f.Name)
+		v := getenv(source)
+		ok := v != ""
+		if !ok {
+			v, ok = file[f.Name]
+			source = c.File
+		}
+		if ok {
+			if serr := f.Value.Set(v); serr != nil {
+				err = fmt.Errorf("%s: invalid %s %q: %v", source, f.Name, v, serr)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// settingNames returns the flag names of the settings that can also come
+// from the environment or the config file.
+func settingNames() map[string]bool {
+	var scratch Config
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	scratch.RegisterFlags(fs)
+	names := map[string]bool{}
+	fs.VisitAll(func(f *flag.Flag) { names[f.Name] = true })
+	delete(names, "config")
+	return names
+}
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// readConfigFile reads name = value lines, skipping blank lines and
+// # comments. Every name must be one of settings.
+func readConfigFile(path string, settings map[string]bool) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s:%d: expected name = value", path, n)
+		}
+		if !settings[name] {
+			return nil, fmt.Errorf("%s:%d: unknown setting %q", path, n, name)
+		}
+		values[name] = value
+	}
+	return values, sc.Err()
+}
+
+// Validate reports the first setting that can't work.
+func (c *Config) Validate() error {
+	switch {
+	case c.Addr == "":
+		return errors.New("addr is required")
+	case c.LobbyExpiry <= 0:
+		return errors.New("lobby-expiry must be positive")
+	case c.CleanupInterval <= 0 || c.CleanupInterval > c.LobbyExpiry:
+		return errors.New("cleanup-interval must be positive and at most lobby-expiry")
+	case c.ExpiryWarning != 0 && (c.ExpiryWarning < c.CleanupInterval || c.ExpiryWarning >= c.LobbyExpiry):
+		return errors.New("expiry-warning must be between cleanup-interval and lobby-expiry, or 0")
+	case c.LogSink != SinkFile && c.LogSink != SinkStdout && c.LogSink != SinkBoth:
+		return errors.New("log-sink must be file, stdout or both")
+	case c.LogMaxSizeMB < 0 || c.LogMaxAge < 0 || c.LogMaxBackups < 0:
+		return errors.New("log rotation limits can't be negative")
+	case c.CodeLength < 3 || c.CodeLength > 12:
+		return errors.New("code-length must be 3 to 12")
+	case c.RecentWords < 0:
+		return errors.New("recent-words can't be negative")
+	case len(c.AllowedOrigins) == 0:
+		return errors.New("allowed-origins needs at least one origin")
+	case c.ReconnectGrace < 0:
+		return errors.New("reconnect-grace can't be negative")
+	case c.HeartbeatInterval <= 0 || c.HeartbeatTimeout <= c.HeartbeatInterval:
+		return errors.New("heartbeat-timeout must be longer than a positive heartbeat-interval")
+	case c.ShutdownTimeout <= 0:
+		return errors.New("shutdown-timeout must be positive")
+	case c.RestartETA < 0:
+		return errors.New("restart-eta can't be negative")
+	}
+	return nil
+}
+
+// Print writes the settings in config file form, for -print-config.
+func (c Config) Print(w io.Writer) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	c.RegisterFlags(fs)
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		fmt.Fprintf(w, "%s = %s\n", f.Name, f.Value)
+	})
+}
+
+func (c *Config) heartbeat() heartbeat {
+	return heartbeat{interval: c.HeartbeatInterval, timeout: c.HeartbeatTimeout}
+}
+
+// originList is a comma-separated list flag.
+type originList []string
+
+func (o *originList) String() string {
+	if o == nil {
+		return ""
+	}
+	return strings.Join(*o, ",")
+}
+
+func (o *originList) Set(v string) error {
+	*o = nil
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*o = append(*o, s)
+		}
+	}
+	return nil
+}
+
+// originAllowed reports whether origin matches one of patterns. A pattern
+// may hold one "*", which matches anything.
+func originAllowed(origin string, patterns []string) bool {
+	for _, p := range patterns {
+		prefix, suffix, wild := strings.Cut(p, "*")
+		if !wild && origin == p {
+			return true
+		}
+		if wild && len(origin) >= len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
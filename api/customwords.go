@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi"
+
+	"imposter/api/protocol"
+)
+
+// Custom word lists let the host upload their own words for a lobby. The list
+// lives on the lobby only, so it is discarded when the lobby expires.
+
+const (
+	maxCustomWordsBody = 32 << 10 // bytes
+	maxCustomWords     = 500
+	maxCustomWordLen   = 40 // runes
+)
+
+// parseCustomWords reads a word list as a JSON array (or {"words": [...]}),
+// CSV, or plain text with one word per line or comma separated.
+func parseCustomWords(contentType string, body io.Reader) ([]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		var words []string
+		if err := json.Unmarshal(data, &words); err == nil {
+			return words, nil
+		}
+		var obj struct {
+			Words []string `json:"words"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, errors.New("expected a JSON array of words")
+		}
+		return obj.Words, nil
+	case "text/csv":
+		r := csv.NewReader(body)
+		r.FieldsPerRecord = -1
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+		words := []string{}
+		for _, rec := range records {
+			words = append(words, rec...)
+		}
+		return words, nil
+	default:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		return strings.FieldsFunc(string(data), func(r rune) bool {
+			return r == '\n' || r == '\r' || r == ','
+		}), nil
+	}
+}
+
+// sanitizeWord strips control characters and collapses whitespace. It returns
+// "" for words that are empty or too long to use.
+func sanitizeWord(w string) string {
+	if !utf8.ValidString(w) {
+		return ""
+	}
+	w = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, w)
+	w = strings.Join(strings.Fields(w), " ")
+	if utf8.RuneCountInString(w) > maxCustomWordLen {
+		return ""
+	}
+	return w
+}
+
+// customCategory is the category hint given for words from a custom list.
+const customCategory = "custom"
+
+// wordPool returns the words a round may draw from: the named packs (all
+// packs when none are named), or the custom list when useCustom is set,
+// mixed with the named packs if any.
+func (m *LobbyManager) wordPool(custom, packs []string, useCustom bool) ([]Word, error) {
+	if !useCustom {
+		return m.packs.Pool(packs)
+	}
+	if len(custom) == 0 {
+		return nil, errors.New("no custom words uploaded")
+	}
+	words := make([]Word, 0, len(custom))
+	for _, w := range custom {
+		words = append(words, Word{Text: w, Category: customCategory})
+	}
+	if len(packs) > 0 {
+		packWords, err := m.packs.Pool(packs)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, packWords...)
+	}
+	return dedupePool(words), nil
+}
+
+// SetCustomWords replaces the lobby's custom word list
+func (m *LobbyManager) SetCustomWords(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	l, ok := m.hostLobby(w, r)
+	if !ok {
+		return
+	}
+
+	raw, err := parseCustomWords(r.Header.Get("Content-Type"), http.MaxBytesReader(w, r.Body, maxCustomWordsBody))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			http.Error(w, fmt.Sprintf("word list must be under %d bytes", maxCustomWordsBody), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	words := make([]string, 0, len(raw))
+	rejected := 0
+	for _, w := range raw {
+		if s := sanitizeWord(w); s != "" {
+			words = append(words, s)
+		} else if strings.TrimSpace(w) != "" {
+			rejected++
+		}
+	}
+	words = dedupeWords(words)
+	if len(words) == 0 {
+		http.Error(w, "word list is empty", http.StatusBadRequest)
+		return
+	}
+	if len(words) > maxCustomWords {
+		http.Error(w, fmt.Sprintf("word list can have at most %d words", maxCustomWords), http.StatusBadRequest)
+		return
+	}
+
+	l.mu.Lock()
+	l.CustomWords = words
+	m.save(l)
+	l.mu.Unlock()
+
+	m.logEvent(EventCustomWords, "code", code, "words", len(words))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protocol.SetCustomWordsResponse{Count: len(words), Rejected: rejected})
+}
+
+// GetCustomWords returns the lobby's custom word list
+func (m *LobbyManager) GetCustomWords(w http.ResponseWriter, r *http.Request) {
+	l, ok := m.hostLobby(w, r)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	words := append([]string{}, l.CustomWords...)
+	l.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protocol.CustomWordsResponse{Words: words})
+}
+
+// DeleteCustomWords clears the lobby's custom word list
+func (m *LobbyManager) DeleteCustomWords(w http.ResponseWriter, r *http.Request) {
+	l, ok := m.hostLobby(w, r)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	l.CustomWords = nil
+	l.UseCustomWords = false
+	m.save(l)
+	l.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protocol.StatusResponse{Status: "custom words cleared"})
+}
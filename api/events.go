@@ -0,0 +1,147 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Events written to the lobby event log, one JSON object per line:
+//
+//	{"time":"...","level":"INFO","event":"player_joined","code":"abcd","player":"Sam","players":3}
+//
+// Durations are in seconds.
+const (
+	EventLobbyCreated      = "lobby_created"
+	EventLobbyRestored     = "lobby_restored"
+	EventLobbyExpiring     = "lobby_expiring"
+	EventLobbyExtended     = "lobby_extended"
+	EventLobbyExpired      = "lobby_expired"
+	EventHostConnected     = "host_connected"
+	EventHostRejected      = "host_rejected"
+	EventPlayerJoined      = "player_joined"
+	EventPlayerReconnected = "player_reconnected"
+	EventPlayerLeft        = "player_left"
+	EventCustomWords       = "custom_words"
+	EventGameStarted       = "game_started"
+	EventGameEnded         = "game_ended"
+	EventRoundFinished     = "round_finished"
+	EventWordVote          = "word_vote"
+	EventWordReplaced      = "word_replaced"
+	EventVoteOpened        = "vote_opened"
+	EventVoteTied          = "vote_tied"
+	EventVote              = "vote"
+	EventGuess             = "guess"
+	EventPhaseChanged      = "phase_changed"
+	EventPhasePaused       = "phase_paused"
+	EventPhaseResumed      = "phase_resumed"
+	EventPhaseSkipped      = "phase_skipped"
+	EventSpeakingOrder     = "speaking_order"
+	EventClue              = "clue"
+	EventShutdown          = "shutdown"
+)
+
+// Where events are written, chosen by Config.LogSink.
+const (
+	SinkFile   = "file"
+	SinkStdout = "stdout"
+	SinkBoth   = "both"
+)
+
+// eventKey replaces slog's "msg" key, which holds the event name.
+const eventKey = "event"
+
+// secretAttrs are event attributes that give the game away. They are
+// replaced when Config.RedactSecrets is set.
+var secretAttrs = map[string]bool{
+	"word":     true,
+	"decoy":    true,
+	"new_word": true,
+	"roles":    true,
+	"role":     true,
+	"guess":    true,
+}
+
+const redacted = "[redacted]"
+
+// newEventLogger builds the event logger described by cfg. The returned
+// closer, if not nil, closes the log file.
+func newEventLogger(cfg Config) (*slog.Logger, io.Closer) {
+	var writers []io.Writer
+	var closer io.Closer
+	if cfg.LogPath != "" && (cfg.LogSink == SinkFile || cfg.LogSink == SinkBoth) {
+		f, err := openRotatingFile(cfg.LogPath, int64(cfg.LogMaxSizeMB)<<20, cfg.LogMaxAge, cfg.LogMaxBackups)
+		if err != nil {
+			log.Println("failed to open log file:", err)
+		} else {
+			writers = append(writers, f)
+			closer = f
+		}
+	}
+	if cfg.LogSink == SinkStdout || cfg.LogSink == SinkBoth {
+		writers = append(writers, os.Stdout)
+	}
+
+	h := slog.NewJSONHandler(io.MultiWriter(writers...), &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch {
+			case len(groups) == 0 && a.Key == slog.MessageKey:
+				a.Key = eventKey
+			case cfg.RedactSecrets && secretAttrs[a.Key]:
+				a.Value = slog.StringValue(redacted)
+			}
+			return a
+		},
+	})
+	return slog.New(h), closer
+}
+
+// logEvent records event with its attributes, given as alternating keys and
+// values like slog.Logger.Info.
+func (m *LobbyManager) logEvent(event string, args ...any) {
+	m.events.Info(event, args...)
+}
+
+// seconds formats a duration for the event log.
+func seconds(d time.Duration) float64 {
+	return d.Round(time.Millisecond).Seconds()
+}
+
+// newWordKey returns a random key for wordID. Pack word lists are public, so
+// ids that could be worked out without the key would give the words away.
+func newWordKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalln("failed to generate word id key:", err)
+	}
+	return key
+}
+
+// wordID names a word in the event log without giving it away, so reports can
+// tell words apart when secrets are redacted: its pack and a short keyed hash,
+// e.g. "animals:1f0c2a9d". Words from the lobby's custom list are in "custom".
+// The key lasts as long as the process, so the same word gets a new id after a
+// restart.
+func (m *LobbyManager) wordID(pack, word string) string {
+	if pack == "" {
+		pack = "custom"
+	}
+	mac := hmac.New(sha256.New, m.wordKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(word))))
+	return pack + ":" + hex.EncodeToString(mac.Sum(nil)[:4])
+}
+
+// logGameStarted records the deal of a new round. Callers must hold l.mu.
+func (m *LobbyManager) logGameStarted(l *Lobby, restart bool) {
+	m.logEvent(EventGameStarted, "code", l.Code, "restart", restart, "mode", l.Mode,
+		"players", len(l.Players), "imposters", l.Imposters, "category", l.GameCategory,
+		"word", l.GameWord, "word_id", m.wordID(l.GamePack, l.GameWord), "decoy", l.DecoyWord,
+		"roles", l.PlayerRole)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"time"
+
+	"imposter/api/protocol"
+)
+
+// Reasons a lobby is closed, sent with lobby_closed.
+const (
+	ClosedExpired = "expired"
+)
+
+// expiresAt is when l closes unless there is more activity.
+// Callers must hold l.mu.
+func (l *Lobby) expiresAt(expiry time.Duration) time.Time {
+	last := l.LastActive
+	if last.IsZero() {
+		last = l.CreatedAt
+	}
+	return last.Add(expiry)
+}
+
+// touch records activity in l, pushing its expiry back. Clients that were
+// warned the lobby was about to close hear that it no longer is.
+// Callers must hold l.mu.
+func (m *LobbyManager) touch(l *Lobby) {
+	l.LastActive = time.Now()
+	if l.expiryWarned {
+		l.expiryWarned = false
+		l.sendAll(m.lobbyExtended(l))
+	}
+}
+
+// extendLobby is the host keeping the lobby open for another full expiry
+// period. Callers must hold l.mu.
+func (m *LobbyManager) extendLobby(l *Lobby) {
+	l.LastActive = time.Now()
+	l.expiryWarned = false
+	l.sendAll(m.lobbyExtended(l))
+	m.logEvent(EventLobbyExtended, "code", l.Code, "expires_at", l.expiresAt(m.cfg.LobbyExpiry))
+}
+
+// lobbyExtended builds the lobby_extended message. Callers must hold l.mu.
+func (m *LobbyManager) lobbyExtended(l *Lobby) *protocol.LobbyExtended {
+	at := l.expiresAt(m.cfg.LobbyExpiry)
+	return &protocol.LobbyExtended{Code: l.Code, ExpiresAt: at, ExpiresIn: int64(time.Until(at).Seconds())}
+}
+
+// warnExpiringLobbies tells everyone in a lobby that is about to expire,
+// once, so the host has a chance to extend it.
+func (m *LobbyManager) warnExpiringLobbies(now time.Time) {
+	if m.cfg.ExpiryWarning <= 0 {
+		return
+	}
+	for _, l := range m.store.List() {
+		l.mu.Lock()
+		at := l.expiresAt(m.cfg.LobbyExpiry)
+		if !l.expiryWarned && now.After(at.Add(-m.cfg.ExpiryWarning)) {
+			l.expiryWarned = true
+			l.sendAll(&protocol.LobbyExpiring{Code: l.Code, ExpiresAt: at, ExpiresIn: int64(at.Sub(now).Seconds())})
+			m.logEvent(EventLobbyExpiring, "code", l.Code, "expires_at", at)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore keeps lobbies in memory and journals every change to a local
+// file of JSON lines, so running games survive a restart. The journal is
+// compacted to one entry per lobby when it is opened and whenever it grows
+// well past the number of live lobbies.
+type FileStore struct {
+	mu        sync.Mutex
+	path      string
+	f         *os.File
+	lobbies   map[string]*Lobby
+	snapshots map[string]json.RawMessage // last journalled state of each lobby
+	entries   int                        // lines in the journal
+}
+
+type journalEntry struct {
+	Op    string          `json:"op"` // "put" or "delete"
+	Code  string          `json:"code"`
+	Lobby json.RawMessage `json:"lobby,omitempty"`
+}
+
+// lobbySnapshot is the journalled form of a lobby: its exported fields plus
+// the round in progress and its phase clock. Connections, seat timers and any
+// open vote are not kept; players reconnect with their session tokens.
+type lobbySnapshot struct {
+	*Lobby
+	Round         *RoundRecord   `json:"round,omitempty"`
+	Guesser       string         `json:"guesser,omitempty"`
+	PendingWinner string         `json:"pending_winner,omitempty"`
+	Phase         *phaseSnapshot `json:"phase,omitempty"`
+}
+
+// compactRatio is how many journal lines per live lobby trigger compaction.
+const compactRatio = 8
+
+// OpenFileStore loads the journal at path, creating it if needed.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:      path,
+		lobbies:   make(map[string]*Lobby),
+		snapshots: make(map[string]json.RawMessage),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	for code, raw := range s.snapshots {
+		l, err := restoreLobby(raw)
+		if err != nil {
+			return nil, fmt.Errorf("lobby journal %s: lobby %s: %w", path, code, err)
+		}
+		s.lobbies[code] = l
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 16<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		var e journalEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			// most likely a write cut short by a crash; everything before it is good
+			log.Printf("lobby journal %s: skipping bad line %d: %v", s.path, line, err)
+			continue
+		}
+		switch e.Op {
+		case "put":
+			s.snapshots[e.Code] = e.Lobby
+		case "delete":
+			delete(s.snapshots, e.Code)
+		}
+	}
+	return sc.Err()
+}
+
+// restoreLobby rebuilds a lobby from its snapshot. Every player starts out
+// disconnected.
+func restoreLobby(raw json.RawMessage) (*Lobby, error) {
+	snap := lobbySnapshot{Lobby: &Lobby{}}
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, err
+	}
+	l := snap.Lobby
+	l.round = snap.Round
+	l.guesser = snap.Guesser
+	l.pendingWinner = snap.PendingWinner
+	if p := snap.Phase; p != nil {
+		// the manager restarts the clock
+		l.phase = &phaseClock{name: p.Name, duration: p.Duration, remaining: p.Remaining, paused: p.Paused}
+	}
+	l.clients = make(map[*client]bool)
+	l.leaving = make(map[string]*time.Timer)
+	if l.Sessions == nil {
+		l.Sessions = make(map[string]string)
+	}
+	if l.PlayerRole == nil {
+		l.PlayerRole = make(map[string]string)
+	}
+	if l.Scores == nil {
+		l.Scores = make(map[string]int)
+	}
+	return l, nil
+}
+
+// compact rewrites the journal with one entry per lobby. Callers must hold
+// s.mu or be the only user of s.
+func (s *FileStore) compact() error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for code, raw := range s.snapshots {
+		if err := enc.Encode(journalEntry{Op: "put", Code: code, Lobby: raw}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	if s.f != nil {
+		s.f.Close()
+	}
+	s.f, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	s.entries = len(s.snapshots)
+	return syncDir(filepath.Dir(s.path))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	_ = d.Sync() // not supported everywhere
+	return nil
+}
+
+// append writes e to the journal. Callers must hold s.mu.
+func (s *FileStore) append(e journalEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.entries++
+	if s.entries > compactRatio*(len(s.snapshots)+1) {
+		return s.compact()
+	}
+	return nil
+}
+
+func (s *FileStore) Get(code string) (*Lobby, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.lobbies[code]
+	return l, ok
+}
+
+func (s *FileStore) Put(l *Lobby) error {
+	raw, err := json.Marshal(lobbySnapshot{
+		Lobby:         l,
+		Round:         l.round,
+		Guesser:       l.guesser,
+		PendingWinner: l.pendingWinner,
+		Phase:         l.phaseSnapshot(),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lobbies[l.Code] = l
+	s.snapshots[l.Code] = raw
+	return s.append(journalEntry{Op: "put", Code: l.Code, Lobby: raw})
+}
+
+func (s *FileStore) Delete(code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lobbies[code]; !ok {
+		return nil
+	}
+	delete(s.lobbies, code)
+	delete(s.snapshots, code)
+	return s.append(journalEntry{Op: "delete", Code: code})
+}
+
+func (s *FileStore) List() []*Lobby {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedLobbies(s.lobbies)
+}
+
+func (s *FileStore) Expire(expired func(*Lobby) bool) ([]*Lobby, error) {
+	var out []*Lobby
+	for _, l := range s.List() {
+		if expired(l) {
+			if err := s.Delete(l.Code); err != nil {
+				return out, err
+			}
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+// Close flushes the journal to disk and closes it.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.f.Sync(); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+
+	"imposter/api/protocol"
+)
+
+// Steal-the-win: an imposter caught by the vote gets one guess at the word.
+// A correct guess hands the round to the imposters.
+
+func (m *LobbyManager) guessWord(l *Lobby, name, guess string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.guesser == "" || l.guesser != name {
+		return errors.New("you cannot guess now")
+	}
+
+	correct := wordsMatch(guess, l.GameWord)
+	m.finishGuess(l, guess, correct)
+	return nil
+}
+
+func (m *LobbyManager) skipGuess(l *Lobby) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.guesser == "" {
+		return errors.New("no guess pending")
+	}
+
+	m.finishGuess(l, "", false)
+	return nil
+}
+
+// finishGuess settles the round outcome and reveals the word to everyone.
+// Callers must hold l.mu.
+func (m *LobbyManager) finishGuess(l *Lobby, guess string, correct bool) {
+	name := l.guesser
+	winner := l.pendingWinner
+	if correct {
+		winner = "imposters"
+	}
+	if correct && l.round != nil {
+		l.round.Points[name] += l.Points.ImposterGuess
+	}
+	l.Winner = winner
+	l.guesser = ""
+	l.pendingWinner = ""
+	m.save(l)
+
+	m.logEvent(EventGuess, "code", l.Code, "player", name, "guess", guess, "correct", correct)
+
+	l.sendAll(&protocol.GuessResult{
+		Code:    l.Code,
+		Name:    name,
+		Guess:   guess,
+		Correct: correct,
+		Word:    l.GameWord,
+		Winner:  winner,
+	})
+	if winner != "" {
+		if l.phase != nil {
+			// nothing left to count down to
+			m.enterPhase(l, PhaseReveal)
+		}
+		m.finishRound(l)
+	}
+}
@@ -3,25 +3,57 @@ package api
 import (
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"math/big"
 	mRand "math/rand"
 	"net/http"
-	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/gorilla/websocket"
+
+	"imposter/api/protocol"
 )
 
 // Minimal lobby + websocket implementation.
 
+// Category hint options, chosen by the host per game.
+const (
+	HintOff       = "off"
+	HintImposters = "imposters"
+	HintEveryone  = "everyone"
+)
+
+// Game modes. In undercover mode imposters are dealt a related decoy word
+// instead of being told they are the imposter.
+const (
+	ModeClassic    = "classic"
+	ModeUndercover = "undercover"
+)
+
 type LobbyManager struct {
-	mu      sync.Mutex
-	lobbies map[string]*Lobby
-	logFile *os.File
+	cfg      Config
+	store    LobbyStore
+	events   *slog.Logger
+	eventLog io.Closer // event log file, nil if there is none
+	packs    *WordPacks
+	recent   *recentWords // words dealt lately in any lobby
+	wordKey  []byte       // keys word ids in the event log
+	upgrader websocket.Upgrader
+
+	reconnectGrace time.Duration
+	heartbeat      heartbeat
+
+	closing     atomic.Bool   // set once Shutdown starts
+	stop        chan struct{} // closed to stop the cleanup goroutine
+	cleanupDone chan struct{}
 }
 
 type Lobby struct {
@@ -30,35 +62,106 @@ type Lobby struct {
 	Imposters          int               `json:"imposters"`
 	GameState          string            `json:"game_state"` // "waiting", "started", "ended"
 	GameWord           string            `json:"game_word"`
-	PlayerWordVotedBad map[string]bool   // track who voted bad word
-	PlayerRole         map[string]string // "imposter" or "word"
+	DecoyWord          string            `json:"decoy_word"` // imposters' word in undercover mode
+	GameCategory       string            `json:"game_category"`
+	GamePack           string            `json:"game_pack"`             // pack GameWord came from, "" for the custom list
+	Hint               string            `json:"hint"`                  // who sees GameCategory: "off", "imposters", "everyone"
+	Mode               string            `json:"mode"`                  // "classic" or "undercover"
+	PlayerWordVotedBad map[string]bool   `json:"player_word_voted_bad"` // track who voted bad word
+	BadWordThreshold   int               `json:"bad_word_threshold"`    // percent of word players, 0 never replaces
+	RejectedWords      []string          `json:"rejected_words"`        // voted out, never dealt again
+	UsedWords          []string          `json:"used_words"`            // every word dealt, in order
+	WordPass           int               `json:"word_pass"`             // index in UsedWords where this pass through the words began
+	PlayerRole         map[string]string `json:"player_role"`           // "imposter" or "word"
+	Eliminated         map[string]bool   `json:"eliminated"`
+	TieRule            string            `json:"tie_rule"`     // "revote", "none", "random"
+	Winner             string            `json:"winner"`       // "", "word", "imposters"
+	Packs              []string          `json:"packs"`        // word packs to draw from, empty for all
+	CustomWords        []string          `json:"custom_words"` // host-uploaded words, host only
+	UseCustomWords     bool              `json:"use_custom_words"`
+	Points             PointsScheme      `json:"points"`
+	Timers             PhaseTimers       `json:"timers"` // phase durations, 0 for no limit
+	Turns              TurnRules         `json:"turns"`
+	SpeakingOrder      []string          `json:"speaking_order"` // this round's clue order
+	Turn               int               `json:"turn"`           // index of the current speaker
+	CluePass           int               `json:"clue_pass"`      // times round the table this round
+	Rounds             []RoundRecord     `json:"rounds"`         // finished rounds of the match
+	Scores             map[string]int    `json:"scores"`         // match totals keyed by player name
 	CreatedAt          time.Time         `json:"created_at"`
-	clients            map[*websocket.Conn]string
-	hostConn           *websocket.Conn // separate connection for host
+	LastActive         time.Time         `json:"last_active"` // the lobby expires when idle too long
+	Sessions           map[string]string `json:"sessions"`    // session token -> player name
+	HostToken          string            `json:"host_token"`  // secret needed for host controls
+	clients            map[*client]bool
+	leaving            map[string]*time.Timer // seats held for dropped players
+	host               *client                // separate connection for host
+	vote               *voteRound             // open vote, nil when not voting
+	guesser            string                 // caught imposter allowed to guess the word
+	pendingWinner      string                 // outcome held back until the guess is in
+	round              *RoundRecord           // round in progress, nil between rounds
+	phase              *phaseClock            // phase of the round, nil between rounds
+	expiryWarned       bool                   // lobby_expiring sent since the last activity
+	closed             bool                   // expired and removed from the store
 	mu                 sync.Mutex
 }
 
-type createLobbyResp struct {
-	Code string `json:"code"`
+type createLobbyResp = protocol.CreateLobbyResponse
+
+// NewLobbyManager keeps lobbies in memory, or in cfg.LobbyJournal so games
+// survive a restart.
+func NewLobbyManager(cfg Config) *LobbyManager {
+	var store LobbyStore = NewMemoryStore()
+	if cfg.LobbyJournal != "" {
+		fs, err := OpenFileStore(cfg.LobbyJournal)
+		if err != nil {
+			log.Fatalln("failed to open lobby journal:", err)
+		}
+		store = fs
+	}
+	return newLobbyManager(cfg, store)
 }
 
-func NewLobbyManager() *LobbyManager {
-	// open or create a log file
-	logFile, err := os.OpenFile("lobbies.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+func newLobbyManager(cfg Config, store LobbyStore) *LobbyManager {
+	events, eventLog := newEventLogger(cfg)
+	packs, err := LoadWordPacks(cfg.WordPacksDir)
 	if err != nil {
-		log.Println("failed to open log file:", err)
+		log.Fatalln("failed to load word packs:", err)
 	}
 	lm := &LobbyManager{
-		lobbies: make(map[string]*Lobby),
-		logFile: logFile,
-	}
-
-	// Start cleanup goroutine to remove expired lobbies every minute
+		cfg:      cfg,
+		store:    store,
+		events:   events,
+		eventLog: eventLog,
+		packs:    packs,
+		recent:   &recentWords{size: cfg.RecentWords},
+		wordKey:  newWordKey(),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				// non-browser clients send no origin
+				origin := r.Header.Get("Origin")
+				return origin == "" || originAllowed(origin, cfg.AllowedOrigins)
+			},
+		},
+
+		reconnectGrace: cfg.ReconnectGrace,
+		heartbeat:      cfg.heartbeat(),
+
+		stop:        make(chan struct{}),
+		cleanupDone: make(chan struct{}),
+	}
+	lm.restoreLobbies()
+
+	// Start cleanup goroutine to remove expired lobbies
 	go func() {
-		ticker := time.NewTicker(1 * time.Minute)
+		defer close(lm.cleanupDone)
+		ticker := time.NewTicker(cfg.CleanupInterval)
 		defer ticker.Stop()
-		for range ticker.C {
-			lm.cleanupExpiredLobbies()
+		for {
+			select {
+			case <-ticker.C:
+				lm.cleanupExpiredLobbies()
+			case <-lm.stop:
+				return
+			}
 		}
 	}()
 
@@ -66,80 +169,131 @@ func NewLobbyManager() *LobbyManager {
 }
 
 func (m *LobbyManager) cleanupExpiredLobbies() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	now := time.Now()
-	expiry := 15 * time.Minute
-
-	for code, lobby := range m.lobbies {
-		if now.Sub(lobby.CreatedAt) > expiry {
-			// Close all WebSocket connections for this lobby
-			lobby.mu.Lock()
-			for conn := range lobby.clients {
-				conn.Close()
-			}
-			lobby.mu.Unlock()
+	expiry := m.cfg.LobbyExpiry
+
+	expired, err := m.store.Expire(func(l *Lobby) bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		// closed before the store drops it, so nothing can save it back
+		if now.After(l.expiresAt(expiry)) {
+			l.closed = true
+		}
+		return l.closed
+	})
+	if err != nil {
+		log.Println("failed to remove expired lobbies:", err)
+	}
+	for _, lobby := range expired {
+		// Tell everyone why, then close all WebSocket connections for this lobby
+		lobby.mu.Lock()
+		lobby.sendAll(&protocol.LobbyClosed{Code: lobby.Code, Reason: ClosedExpired})
+		for c := range lobby.clients {
+			c.close()
+		}
+		if lobby.host != nil {
+			lobby.host.close()
+		}
+		lobby.CustomWords = nil
+		lobby.stopSeatTimers()
+		lobby.stopPhase()
+		players, age := len(lobby.Players), now.Sub(lobby.CreatedAt)
+		lobby.mu.Unlock()
+
+		m.logEvent(EventLobbyExpired, "code", lobby.Code, "players", players, "age", seconds(age))
+	}
+
+	m.warnExpiringLobbies(now)
+}
 
-			// Remove the lobby
-			delete(m.lobbies, code)
-			m.logEvent("Lobby expired and removed: %s", code)
+// restoreLobbies picks up lobbies the store kept from a previous run. Every
+// player's seat is held for the usual grace period, so players who don't
+// come back are released.
+func (m *LobbyManager) restoreLobbies() {
+	for _, l := range m.store.List() {
+		l.mu.Lock()
+		// time spent down doesn't count against the lobby
+		l.LastActive = time.Now()
+		for _, name := range l.Players {
+			m.reserveSeat(l, name)
+		}
+		if p := l.phase; p != nil && !p.paused {
+			m.runPhase(l, p.remaining)
 		}
+		l.mu.Unlock()
+		m.logEvent(EventLobbyRestored, "code", l.Code, "players", len(l.Players))
 	}
 }
 
-func (m *LobbyManager) logEvent(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logLine := fmt.Sprintf("[%s] %s\n", timestamp, msg)
-	log.Print(logLine)
-	if m.logFile != nil {
-		m.logFile.WriteString(logLine)
+// save writes l back to the store, unless it has been closed.
+// Callers must hold l.mu.
+func (m *LobbyManager) save(l *Lobby) {
+	if l.closed {
+		return
+	}
+	if err := m.store.Put(l); err != nil {
+		log.Printf("failed to save lobby %s: %v", l.Code, err)
 	}
 }
 
 func (m *LobbyManager) CreateLobby(w http.ResponseWriter, r *http.Request) {
-	code := generateCode(4)
+	if m.closing.Load() {
+		http.Error(w, errShuttingDown.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	code := generateCode(m.cfg.CodeLength)
 	l := &Lobby{
-		Code:       code,
-		Players:    []string{},
-		Imposters:  0,
-		GameState:  "waiting",
-		GameWord:   "",
-		PlayerRole: make(map[string]string),
-		CreatedAt:  time.Now(),
-		clients:    make(map[*websocket.Conn]string),
+		Code:             code,
+		Players:          []string{},
+		Imposters:        0,
+		GameState:        "waiting",
+		GameWord:         "",
+		PlayerRole:       make(map[string]string),
+		Mode:             ModeClassic,
+		Hint:             HintOff,
+		TieRule:          TieRevote,
+		Points:           DefaultPoints,
+		BadWordThreshold: DefaultBadWordThreshold,
+		Scores:           make(map[string]int),
+		CreatedAt:        time.Now(),
+		LastActive:       time.Now(),
+		Sessions:         make(map[string]string),
+		HostToken:        newSessionToken(),
+		clients:          make(map[*client]bool),
+		leaving:          make(map[string]*time.Timer),
 	}
 
-	m.mu.Lock()
-	m.lobbies[code] = l
-	m.mu.Unlock()
+	l.mu.Lock()
+	m.save(l)
+	l.mu.Unlock()
 
-	m.logEvent("Lobby created: %s", code)
+	m.logEvent(EventLobbyCreated, "code", code)
 
+	// browsers send the cookie back automatically; other clients use the header.
+	// It lasts the browser session, as an active lobby has no fixed lifetime.
+	http.SetCookie(w, &http.Cookie{
+		Name:     hostCookieName(code),
+		Value:    l.HostToken,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(createLobbyResp{Code: code})
+	json.NewEncoder(w).Encode(createLobbyResp{Code: code, HostToken: l.HostToken})
 }
 
 func (m *LobbyManager) GetLobby(w http.ResponseWriter, r *http.Request) {
 	code := chi.URLParam(r, "code")
-	m.mu.Lock()
-	l, ok := m.lobbies[code]
-	m.mu.Unlock()
+	l, ok := m.store.Get(code)
 	if !ok {
 		http.Error(w, "lobby not found", http.StatusNotFound)
 		return
 	}
 
 	l.mu.Lock()
-	expiresAt := l.CreatedAt.Add(15 * time.Minute)
+	expiresAt := l.expiresAt(m.cfg.LobbyExpiry)
 	timeRemaining := time.Until(expiresAt)
-	resp := struct {
-		Code      string    `json:"code"`
-		Players   []string  `json:"players"`
-		ExpiresIn int64     `json:"expires_in"` // seconds
-		ExpiresAt time.Time `json:"expires_at"`
-	}{
+	resp := protocol.LobbyInfo{
 		Code:      l.Code,
 		Players:   append([]string(nil), l.Players...),
 		ExpiresIn: int64(timeRemaining.Seconds()),
@@ -151,210 +305,572 @@ func (m *LobbyManager) GetLobby(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
-}
-
 func (m *LobbyManager) ServeWS(w http.ResponseWriter, r *http.Request) {
 	code := chi.URLParam(r, "code")
+	if m.closing.Load() {
+		http.Error(w, errShuttingDown.Error(), http.StatusServiceUnavailable)
+		return
+	}
 
-	m.mu.Lock()
-	l, ok := m.lobbies[code]
-	m.mu.Unlock()
+	l, ok := m.store.Get(code)
 	if !ok {
 		http.Error(w, "lobby not found", http.StatusNotFound)
 		return
 	}
 
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := m.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println("ws upgrade error:", err)
 		return
 	}
+	c := newClient(conn, m.heartbeat)
 
 	// Wait for join message with player name. Accept name via query param to avoid race.
-	var joinMsg map[string]any
-	// If client provided name in query params (e.g., ?name=Host), use that immediately
-	if qname := r.URL.Query().Get("name"); qname != "" {
-		joinMsg = map[string]any{"type": "join", "name": qname}
+	var join *protocol.Join
+	// If client provided name in query params (e.g., ?name=Host), use that immediately.
+	// A session token from an earlier join (?token=...) resumes that identity.
+	q := r.URL.Query()
+	if qname, qtoken := q.Get("name"), q.Get("token"); qname != "" || qtoken != "" {
+		join = &protocol.Join{Name: qname, Token: qtoken, HostToken: q.Get("host_token")}
+		join.V, _ = strconv.Atoi(q.Get("v"))
 	} else {
-		if err := conn.ReadJSON(&joinMsg); err != nil {
+		data, err := c.read()
+		if err != nil {
 			log.Println("failed to read join message:", err)
-			conn.Close()
+			c.close()
+			return
+		}
+		msg, err := protocol.Decode(data)
+		if err == nil {
+			if join, ok = msg.(*protocol.Join); !ok {
+				err = protocol.Errorf(protocol.ReasonJoinRequired, "first message must be join")
+			}
+		}
+		if err != nil {
+			_ = c.send(err.(*protocol.ErrorMessage))
+			c.close()
 			return
 		}
 	}
 
-	if joinMsg["type"] != "join" {
-		conn.WriteJSON(map[string]string{"error": "first message must be join"})
-		conn.Close()
+	version, err := protocol.Negotiate(join.V)
+	if err != nil {
+		_ = c.send(err.(*protocol.ErrorMessage))
+		c.close()
 		return
 	}
+	c.version = version
 
-	name, ok := joinMsg["name"].(string)
-	if !ok || name == "" {
-		conn.WriteJSON(map[string]string{"error": "name required"})
-		conn.Close()
-		return
+	name, token, hostToken := join.Name, join.Token, join.HostToken
+	if hostToken == "" {
+		hostToken = requestHostToken(r, code)
 	}
 
 	// register
 	l.mu.Lock()
-	isHost := name == "Host"
+	if l.closed {
+		// the lobby expired while this socket was connecting
+		l.mu.Unlock()
+		_ = c.send(&protocol.LobbyClosed{Code: code, Reason: ClosedExpired})
+		c.close()
+		return
+	}
+	resumed := false
+	if n, ok := l.Sessions[token]; ok && token != "" {
+		name = n
+		resumed = true
+	}
+	isHost := resumed && name == hostName
+	switch {
+	case resumed:
+	case name == hostName:
+		// only the lobby creator may take the host slot
+		if err := l.checkHostToken(hostToken); err != nil {
+			l.mu.Unlock()
+			m.logEvent(EventHostRejected, "code", code, "remote", r.RemoteAddr)
+			_ = c.send(protocol.Errorf(protocol.ReasonUnauthorized, "%v", err))
+			c.close()
+			return
+		}
+		isHost = true
+	default:
+		var err error
+		if name, err = l.validateName(name); err != nil {
+			l.mu.Unlock()
+			rej := err.(*joinRejection)
+			_ = c.send(&protocol.JoinRejected{Reason: rej.Reason, Message: rej.Message, Suggestion: rej.Suggestion})
+			c.close()
+			return
+		}
+	}
+	c.name = name
+	if !resumed {
+		token = newSessionToken()
+		l.Sessions[token] = name
+	}
+	// a seat is still held if the player dropped within the grace period or
+	// their old socket has not noticed it is gone yet
+	if t, ok := l.leaving[name]; ok {
+		t.Stop()
+		delete(l.leaving, name)
+	}
+	seatHeld := resumed && !isHost && contains(l.Players, name)
 	if isHost {
-		l.hostConn = conn
+		l.host = c
 	} else {
-		l.clients[conn] = name
-		l.Players = append(l.Players, name)
+		// others only saw the player go away if no socket was left open
+		wasAway := !l.connected(name)
+		// a resumed identity replaces any stale socket (e.g. an old tab)
+		for old := range l.clients {
+			if old.name == name && resumed {
+				delete(l.clients, old)
+				old.close()
+			}
+		}
+		l.clients[c] = true
+		if !seatHeld {
+			l.Players = append(l.Players, name)
+		} else if wasAway {
+			l.sendStatus(name, StatusOnline)
+		}
 	}
-	// capture current game state/word/role for use below
+	m.touch(l)
+	m.save(l)
+	// capture current game state and this player's start message for use below
 	currentState := l.GameState
-	currentWord := l.GameWord
-	// copy role map reference
-	roleMap := l.PlayerRole
+	startedMsg := l.gameStartedMsg(name)
+	var phaseMsg *protocol.PhaseChanged
+	var orderMsg *protocol.SpeakingOrder
+	var boardMsg *protocol.ClueBoard
+	if l.phase != nil {
+		phaseMsg = l.phaseChanged()
+		orderMsg = l.speakingOrder()
+		boardMsg = l.clueBoard()
+	}
+	playerCount := len(l.Players)
 	l.mu.Unlock()
 
 	if isHost {
-		m.logEvent("Host connected to lobby %s", code)
-		// Send host confirmation
-		_ = conn.WriteJSON(map[string]any{"type": "host_ready", "code": code})
-		// Send host the current player list immediately
-		m.broadcastLobby(l)
+		m.logEvent(EventHostConnected, "code", code, "resumed", resumed)
+		// Send host confirmation with its session token
+		_ = c.send(&protocol.HostReady{Code: code, Token: token})
+		if resumed {
+			// players haven't changed, only the reconnecting host needs the list
+			m.sendLobbyState(l, c, token)
+		} else {
+			// Send host the current player list immediately
+			m.broadcastLobby(l)
+		}
 		// If a game is already in progress, notify host with player count
 		if currentState == "started" {
 			l.mu.Lock()
 			count := len(l.Players)
 			l.mu.Unlock()
-			_ = conn.WriteJSON(map[string]any{"type": "game_started", "code": code, "count": count})
+			_ = c.send(&protocol.GameStarted{Code: code, Count: count})
+		}
+		if phaseMsg != nil {
+			_ = c.send(orderMsg)
+			_ = c.send(boardMsg)
+			_ = c.send(phaseMsg)
 		}
 	} else {
-		m.logEvent("Player joined lobby %s: %s (total players: %d)", code, name, len(l.Players))
+		if seatHeld {
+			m.logEvent(EventPlayerReconnected, "code", code, "player", name)
+		} else {
+			m.logEvent(EventPlayerJoined, "code", code, "player", name, "players", playerCount)
+		}
 		// If a game is already in progress, send this player their role/word immediately
 		if currentState == "started" {
-			role := "word"
-			if roleMap != nil {
-				if r, ok := roleMap[name]; ok {
-					role = r
-				}
-			}
-			msg := map[string]any{"type": "game_started", "role": role, "code": code}
-			if role == "word" {
-				msg["word"] = currentWord
-			}
-			_ = conn.WriteJSON(msg)
+			_ = c.send(startedMsg)
+		}
+		if phaseMsg != nil {
+			_ = c.send(orderMsg)
+			_ = c.send(boardMsg)
+			_ = c.send(phaseMsg)
+		}
+		if seatHeld {
+			// nobody else needs to hear about a resumed seat
+			m.sendLobbyState(l, c, token)
+		} else {
+			// broadcast state to all players (so host sees updates and other players)
+			m.broadcastLobby(l)
 		}
-		// broadcast state to all players (so host sees updates and other players)
-		m.broadcastLobby(l)
 	}
 
 	// read loop
-	for {
-		var msg map[string]any
-		if err := conn.ReadJSON(&msg); err != nil {
+	left := false
+	for !left {
+		data, err := c.read()
+		if err != nil {
 			break
 		}
-		// Handle other message types here
-		if t, ok := msg["type"].(string); ok {
-			switch t {
-			case "start":
-				m.broadcastMessage(l, map[string]any{"type": "start_game"})
-			case "vote_bad":
-				// vote message: { type: "vote_bad", voted: true/false }
-				if v, ok := msg["voted"].(bool); ok {
-					l.mu.Lock()
-					if l.PlayerWordVotedBad == nil {
-						l.PlayerWordVotedBad = make(map[string]bool)
-					}
-					if v {
-						l.PlayerWordVotedBad[name] = true
-					} else {
-						delete(l.PlayerWordVotedBad, name)
-					}
-					voteCount := len(l.PlayerWordVotedBad)
-					l.mu.Unlock()
-					// broadcast updated vote count to host and players
-					voteMsg := map[string]any{"type": "word_vote_update", "count": voteCount, "code": code}
-					m.broadcastMessage(l, voteMsg)
-					if l.hostConn != nil {
-						_ = l.hostConn.WriteJSON(voteMsg)
-					}
-				}
+		msg, err := protocol.Decode(data)
+		if err != nil {
+			l.sendError(c, err)
+			continue
+		}
+		l.mu.Lock()
+		m.touch(l)
+		l.mu.Unlock()
+		switch msg := msg.(type) {
+		case *protocol.Leave:
+			// explicit leave gives up the seat instead of holding it for a reconnect
+			left = true
+		case *protocol.Start:
+			m.broadcastMessage(l, &protocol.StartGame{})
+		case *protocol.VoteBad:
+			l.mu.Lock()
+			if l.PlayerWordVotedBad == nil {
+				l.PlayerWordVotedBad = make(map[string]bool)
+			}
+			if msg.Voted {
+				l.PlayerWordVotedBad[name] = true
+			} else {
+				delete(l.PlayerWordVotedBad, name)
+			}
+			voteCount := len(l.PlayerWordVotedBad)
+			wordVotes, _ := l.wordVotes()
+			m.save(l)
+			m.logEvent(EventWordVote, "code", code, "player", name, "bad", msg.Voted,
+				"votes", voteCount, "word_votes", wordVotes, "players", len(l.Players),
+				"word", l.GameWord, "word_id", m.wordID(l.GamePack, l.GameWord))
+			l.mu.Unlock()
+			// broadcast updated vote count to host and players
+			voteMsg := &protocol.WordVoteUpdate{Code: code, Count: voteCount}
+			m.broadcastMessage(l, voteMsg)
+			l.mu.Lock()
+			if l.host != nil {
+				_ = l.host.send(voteMsg)
+			}
+			if l.wordRejected() {
+				m.replaceWord(l)
+			}
+			l.mu.Unlock()
+		case *protocol.OpenVote:
+			if err := requireHost(isHost); err != nil {
+				l.sendError(c, err)
+			} else if err := m.openVote(l); err != nil {
+				l.sendError(c, err)
+			}
+		case *protocol.CastVote:
+			if err := requirePlayer(isHost); err != nil {
+				l.sendError(c, err)
+			} else if err := m.castVote(l, name, msg.Suspect); err != nil {
+				l.sendError(c, err)
+			}
+		case *protocol.CloseVote:
+			// tally whatever votes are in
+			if err := requireHost(isHost); err != nil {
+				l.sendError(c, err)
+			} else if err := m.closeVote(l); err != nil {
+				l.sendError(c, err)
+			}
+		case *protocol.GuessWord:
+			if err := requirePlayer(isHost); err != nil {
+				l.sendError(c, err)
+			} else if err := m.guessWord(l, name, msg.Guess); err != nil {
+				l.sendError(c, err)
+			}
+		case *protocol.SkipGuess:
+			// give up waiting for the imposter's guess
+			if err := requireHost(isHost); err != nil {
+				l.sendError(c, err)
+			} else if err := m.skipGuess(l); err != nil {
+				l.sendError(c, err)
+			}
+		case *protocol.Extend:
+			if err := requireHost(isHost); err != nil {
+				l.sendError(c, err)
+			} else {
+				l.mu.Lock()
+				m.extendLobby(l)
+				l.mu.Unlock()
+			}
+		case *protocol.PausePhase:
+			if err := requireHost(isHost); err != nil {
+				l.sendError(c, err)
+			} else if err := m.pausePhase(l); err != nil {
+				l.sendError(c, err)
+			}
+		case *protocol.ResumePhase:
+			if err := requireHost(isHost); err != nil {
+				l.sendError(c, err)
+			} else if err := m.resumePhase(l); err != nil {
+				l.sendError(c, err)
+			}
+		case *protocol.SkipPhase:
+			if err := requireHost(isHost); err != nil {
+				l.sendError(c, err)
+			} else if err := m.skipPhase(l); err != nil {
+				l.sendError(c, err)
 			}
+		case *protocol.SubmitClue:
+			if err := requirePlayer(isHost); err != nil {
+				l.sendError(c, err)
+			} else if err := m.submitClue(l, name, msg.Clue); err != nil {
+				l.sendError(c, err)
+			}
+		case *protocol.NextTurn:
+			if err := m.nextTurn(l, name, isHost); err != nil {
+				l.sendError(c, err)
+			}
+		case *protocol.Join:
+			l.sendError(c, protocol.Errorf(protocol.ReasonRejected, "already joined"))
 		}
 	}
 
 	// cleanup on disconnect
 	l.mu.Lock()
+	released := false
 	if isHost {
-		l.hostConn = nil
-	} else {
-		delete(l.clients, conn)
-		// remove from Players slice
-		for i, p := range l.Players {
-			if p == name {
-				l.Players = append(l.Players[:i], l.Players[i+1:]...)
-				break
-			}
+		if l.host == c {
+			l.host = nil
+		}
+	} else if l.clients[c] {
+		delete(l.clients, c)
+		if left {
+			l.removePlayer(name)
+			l.sendStatus(name, StatusGone)
+			m.save(l)
+			released = true
+		} else if !l.connected(name) && !m.closing.Load() && !l.closed {
+			// hold the seat in case this is just a page refresh
+			m.reserveSeat(l, name)
 		}
 	}
 	l.mu.Unlock()
 
-	m.broadcastLobby(l)
-	conn.Close()
+	if released {
+		m.logEvent(EventPlayerLeft, "code", code, "player", name, "reason", "left")
+		m.broadcastLobby(l)
+	}
+	c.close()
+}
+
+func requireHost(isHost bool) error {
+	if !isHost {
+		return protocol.Errorf(protocol.ReasonForbidden, "only the host can do that")
+	}
+	return nil
+}
+
+func requirePlayer(isHost bool) error {
+	if isHost {
+		return protocol.Errorf(protocol.ReasonForbidden, "only players can do that")
+	}
+	return nil
+}
+
+// lobbyState builds the lobby_state message for the holder of token, which
+// is echoed back so clients can store it for reconnecting. Callers must
+// hold l.mu.
+func (l *Lobby) lobbyState(token string) *protocol.LobbyState {
+	away := []string{}
+	for _, p := range l.Players {
+		if !l.connected(p) {
+			away = append(away, p)
+		}
+	}
+	return &protocol.LobbyState{
+		Code:    l.Code,
+		Players: append([]string{}, l.Players...),
+		Away:    away,
+		Token:   token,
+	}
 }
 
 func (m *LobbyManager) broadcastLobby(l *Lobby) {
 	l.mu.Lock()
-	state := map[string]any{"type": "lobby_state", "code": l.Code, "players": append([]string(nil), l.Players...)}
 	for c := range l.clients {
-		_ = c.WriteJSON(state)
+		_ = c.send(l.lobbyState(l.tokenFor(c.name)))
 	}
 	// Also send to host so they see player updates
-	if l.hostConn != nil {
-		_ = l.hostConn.WriteJSON(state)
+	if l.host != nil {
+		_ = l.host.send(l.lobbyState(l.tokenFor(hostName)))
 	}
 	l.mu.Unlock()
 }
 
-func (m *LobbyManager) broadcastMessage(l *Lobby, msg any) {
+// sendLobbyState sends the lobby state to a single connection.
+func (m *LobbyManager) sendLobbyState(l *Lobby, c *client, token string) {
+	l.mu.Lock()
+	_ = c.send(l.lobbyState(token))
+	l.mu.Unlock()
+}
+
+// gameStartedMsg builds the game_started message for a player. In undercover
+// mode imposters get the decoy word in exactly the same shape as everyone
+// else, so the payload gives nothing away; for the same reason the category
+// hint goes to everyone in undercover mode. Callers must hold l.mu.
+func (l *Lobby) gameStartedMsg(name string) *protocol.GameStarted {
+	role, ok := l.PlayerRole[name]
+	if !ok {
+		role = "word"
+	}
+	msg := &protocol.GameStarted{Code: l.Code, Role: role}
+	switch {
+	case l.Mode == ModeUndercover:
+		msg.Role = "word"
+		if role == "imposter" {
+			msg.Word = l.DecoyWord
+		} else {
+			msg.Word = l.GameWord
+		}
+	case role == "word":
+		msg.Word = l.GameWord
+	}
+
+	showCategory := l.Hint == HintEveryone ||
+		(l.Hint == HintImposters && (role == "imposter" || l.Mode == ModeUndercover))
+	if showCategory {
+		msg.Category = l.GameCategory
+	}
+	return msg
+}
+
+func (m *LobbyManager) broadcastMessage(l *Lobby, msg protocol.Message) {
 	l.mu.Lock()
 	for c := range l.clients {
-		_ = c.WriteJSON(msg)
+		_ = c.send(msg)
 	}
 	l.mu.Unlock()
 }
 
 func (m *LobbyManager) StartGame(w http.ResponseWriter, r *http.Request) {
-	code := chi.URLParam(r, "code")
-	var req struct {
-		Imposters int `json:"imposters"`
+	l, ok := m.hostLobby(w, r)
+	if !ok {
+		return
 	}
+	var req protocol.StartGameRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request", http.StatusBadRequest)
 		return
 	}
-
-	m.mu.Lock()
-	l, ok := m.lobbies[code]
-	m.mu.Unlock()
-	if !ok {
-		http.Error(w, "lobby not found", http.StatusNotFound)
+	if err := validateStartRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	// Validate imposters count
-	if req.Imposters < 1 || req.Imposters >= len(l.Players) {
-		http.Error(w, fmt.Sprintf("imposters must be 1 to %d", len(l.Players)-1), http.StatusBadRequest)
+	err := m.dealGame(l, &req, false)
+	l.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	l.Imposters = req.Imposters
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protocol.StatusResponse{Status: "game started"})
+}
+
+// validateStartRequest checks the settings a start or restart asks for
+func validateStartRequest(req *protocol.StartGameRequest) error {
+	if req.Mode != "" && req.Mode != ModeClassic && req.Mode != ModeUndercover {
+		return errors.New("mode must be classic or undercover")
+	}
+	if req.Hint != "" && req.Hint != HintOff && req.Hint != HintImposters && req.Hint != HintEveryone {
+		return errors.New("hint must be off, imposters or everyone")
+	}
+	if req.TieRule != "" && !validTieRule(req.TieRule) {
+		return errors.New("tie_rule must be revote, none or random")
+	}
+	if req.Points != nil {
+		if err := validatePoints(*req.Points); err != nil {
+			return err
+		}
+	}
+	if req.Timers != nil {
+		if err := validateTimers(*req.Timers); err != nil {
+			return err
+		}
+	}
+	if req.BadWordThreshold != nil {
+		if err := validateBadWordThreshold(*req.BadWordThreshold); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dealGame applies the settings in req and deals a new game: a word, roles
+// and a speaking order. A restart keeps the lobby's imposter count unless req
+// gives one. Nothing changes if the lobby can't be dealt. Callers must hold
+// l.mu.
+func (m *LobbyManager) dealGame(l *Lobby, req *protocol.StartGameRequest, restart bool) error {
+	imposters := req.Imposters
+	if restart && imposters <= 0 {
+		imposters = l.Imposters
+	}
+	if imposters < 1 || imposters >= len(l.Players) {
+		return fmt.Errorf("imposters must be 1 to %d", len(l.Players)-1)
+	}
+
+	packs := l.Packs
+	if req.Packs != nil {
+		packs = req.Packs
+	}
+	useCustom := l.UseCustomWords
+	if req.CustomWords != nil {
+		useCustom = *req.CustomWords
+	}
+	mode := l.Mode
+	if req.Mode != "" {
+		mode = req.Mode
+	}
+	var pool []Word
+	var groups []WordGroup
+	var err error
+	if mode == ModeUndercover {
+		// undercover needs related words, which only packs provide
+		groups, err = m.packs.GroupPool(packs)
+		if err == nil && len(groups) == 0 {
+			err = errors.New("undercover mode needs word packs with related word groups")
+		}
+		if groups = l.playableGroups(groups); err == nil && len(groups) == 0 {
+			err = errWordsRejected
+		}
+	} else {
+		pool, err = m.wordPool(l.CustomWords, packs, useCustom)
+		if pool = l.playableWords(pool); err == nil && len(pool) == 0 {
+			err = errWordsRejected
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	l.Imposters = imposters
+	if req.TieRule != "" {
+		l.TieRule = req.TieRule
+	}
+	if req.Points != nil {
+		l.Points = *req.Points
+	}
+	if req.Timers != nil {
+		l.Timers = *req.Timers
+	}
+	if req.Turns != nil {
+		l.Turns = *req.Turns
+	}
+	if req.BadWordThreshold != nil {
+		l.BadWordThreshold = *req.BadWordThreshold
+	}
+	l.Packs = packs
+	l.UseCustomWords = useCustom
+	l.Mode = mode
+	if req.Hint != "" {
+		l.Hint = req.Hint
+	}
+	m.finishRound(l)
+	l.resetRound()
 	l.GameState = "started"
-	idx, _ := rand.Int(rand.Reader, big.NewInt(int64(len(GameWords))))
-	l.GameWord = GameWords[idx.Int64()]
+	var word, decoy Word
+	if mode == ModeUndercover {
+		word, decoy = m.drawPair(l, groups)
+	} else {
+		word = m.drawWord(l, pool)
+	}
+	l.GameWord, l.DecoyWord, l.GameCategory, l.GamePack = word.Text, decoy.Text, word.Category, word.Pack
 	l.PlayerRole = make(map[string]string)
 
 	// Shuffle players
@@ -363,7 +879,7 @@ func (m *LobbyManager) StartGame(w http.ResponseWriter, r *http.Request) {
 	})
 
 	// Assign roles
-	impostersNeeded := req.Imposters
+	impostersNeeded := imposters
 	for _, player := range l.Players {
 		if impostersNeeded > 0 {
 			l.PlayerRole[player] = "imposter"
@@ -372,76 +888,59 @@ func (m *LobbyManager) StartGame(w http.ResponseWriter, r *http.Request) {
 			l.PlayerRole[player] = "word"
 		}
 	}
+	l.beginRound()
 
-	m.logEvent("Game started in lobby %s with word '%s' and %d imposters", code, l.GameWord, req.Imposters)
+	m.logGameStarted(l, restart)
 
 	// Broadcast game start with roles to each player
-	for c, name := range l.clients {
-		role := l.PlayerRole[name]
-		msg := map[string]any{
-			"type": "game_started",
-			"role": role,
-			"code": code,
-		}
-		if role == "word" {
-			msg["word"] = l.GameWord
-		}
-		_ = c.WriteJSON(msg)
+	for c := range l.clients {
+		_ = c.send(l.gameStartedMsg(c.name))
 	}
 
 	// Send game started notification to host
-	if l.hostConn != nil {
-		hostMsg := map[string]any{
-			"type":  "game_started",
-			"code":  code,
-			"count": len(l.Players),
-		}
-		_ = l.hostConn.WriteJSON(hostMsg)
+	if l.host != nil {
+		_ = l.host.send(&protocol.GameStarted{Code: l.Code, Count: len(l.Players)})
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "game started"})
+	m.beginClues(l)
+	m.save(l)
+	return nil
 }
 
 // EndGame ends the current game and notifies all clients to return to the lobby
 func (m *LobbyManager) EndGame(w http.ResponseWriter, r *http.Request) {
 	code := chi.URLParam(r, "code")
-
-	m.mu.Lock()
-	l, ok := m.lobbies[code]
-	m.mu.Unlock()
+	l, ok := m.hostLobby(w, r)
 	if !ok {
-		http.Error(w, "lobby not found", http.StatusNotFound)
 		return
 	}
 
 	l.mu.Lock()
 	l.GameState = "ended"
+	l.vote = nil
+	l.stopPhase()
+	m.finishRound(l)
+	m.save(l)
+	players := len(l.Players)
 	l.mu.Unlock()
 
-	m.logEvent("Game ended in lobby %s", code)
+	m.logEvent(EventGameEnded, "code", code, "players", players)
 
 	// broadcast game_ended to all players
-	msg := map[string]any{"type": "game_ended", "code": code}
 	l.mu.Lock()
-	for c := range l.clients {
-		_ = c.WriteJSON(msg)
-	}
-	if l.hostConn != nil {
-		_ = l.hostConn.WriteJSON(msg)
-	}
+	l.sendAll(&protocol.GameEnded{Code: code})
 	l.mu.Unlock()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "game ended"})
+	json.NewEncoder(w).Encode(protocol.StatusResponse{Status: "game ended"})
 }
 
 // RestartGame assigns a new word and roles and broadcasts a new game_started to all players
 func (m *LobbyManager) RestartGame(w http.ResponseWriter, r *http.Request) {
-	code := chi.URLParam(r, "code")
-	var req struct {
-		Imposters int `json:"imposters"`
+	l, ok := m.hostLobby(w, r)
+	if !ok {
+		return
 	}
+	var req protocol.StartGameRequest
 	// body is optional; if provided we'll use it
 	if r.Body != nil {
 		// only attempt to decode if there's content
@@ -449,78 +948,33 @@ func (m *LobbyManager) RestartGame(w http.ResponseWriter, r *http.Request) {
 			_ = json.NewDecoder(r.Body).Decode(&req)
 		}
 	}
-
-	m.mu.Lock()
-	l, ok := m.lobbies[code]
-	m.mu.Unlock()
-	if !ok {
-		http.Error(w, "lobby not found", http.StatusNotFound)
+	if err := validateStartRequest(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	// determine imposters count to use
-	imposters := req.Imposters
-	if imposters <= 0 {
-		imposters = l.Imposters
-	}
-	if imposters < 1 || imposters >= len(l.Players) {
-		http.Error(w, fmt.Sprintf("imposters must be 1 to %d", len(l.Players)-1), http.StatusBadRequest)
+	err := m.dealGame(l, &req, true)
+	l.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	l.Imposters = imposters
-	l.GameState = "started"
-	idx, _ := rand.Int(rand.Reader, big.NewInt(int64(len(GameWords))))
-	l.GameWord = GameWords[idx.Int64()]
-	l.PlayerRole = make(map[string]string)
-
-	// Shuffle players
-	mRand.Shuffle(len(l.Players), func(i, j int) {
-		l.Players[i], l.Players[j] = l.Players[j], l.Players[i]
-	})
-
-	// Assign roles
-	impostersNeeded := imposters
-	for _, player := range l.Players {
-		if impostersNeeded > 0 {
-			l.PlayerRole[player] = "imposter"
-			impostersNeeded--
-		} else {
-			l.PlayerRole[player] = "word"
-		}
-	}
-
-	m.logEvent("Game restarted in lobby %s with word '%s' and %d imposters", code, l.GameWord, imposters)
-
-	// Broadcast game start with roles to each player
-	for c, name := range l.clients {
-		role := l.PlayerRole[name]
-		msg := map[string]any{
-			"type": "game_started",
-			"role": role,
-			"code": code,
-		}
-		if role == "word" {
-			msg["word"] = l.GameWord
-		}
-		_ = c.WriteJSON(msg)
-	}
-
-	// Send game started notification to host
-	if l.hostConn != nil {
-		hostMsg := map[string]any{
-			"type":  "game_started",
-			"code":  code,
-			"count": len(l.Players),
-		}
-		_ = l.hostConn.WriteJSON(hostMsg)
-	}
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "game restarted"})
+	json.NewEncoder(w).Encode(protocol.StatusResponse{Status: "game restarted"})
+}
+
+// resetRound clears per-round voting state. Callers must hold l.mu.
+func (l *Lobby) resetRound() {
+	l.Eliminated = make(map[string]bool)
+	l.PlayerWordVotedBad = make(map[string]bool)
+	l.Winner = ""
+	l.vote = nil
+	l.guesser = ""
+	l.pendingWinner = ""
+	l.CluePass = 0
+	l.stopPhase()
 }
 
 func generateCode(n int) string {
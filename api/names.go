@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Player name validation. Names are trimmed and NFC normalised for display,
+// and compared case-insensitively under NFKC so "Sam", "SAM" and "Ｓａｍ" are
+// all the same player.
+
+const maxNameLen = 20 // runes
+
+// hostName is the name the host socket joins with.
+const hostName = "Host"
+
+// reservedNames can't be used by players, in any case.
+var reservedNames = map[string]bool{
+	"host":     true,
+	"admin":    true,
+	"server":   true,
+	"system":   true,
+	"everyone": true,
+	"imposter": true,
+}
+
+// Reasons given in a join_rejected message.
+const (
+	RejectNameRequired = "name_required"
+	RejectNameTooLong  = "name_too_long"
+	RejectNameInvalid  = "name_invalid"
+	RejectNameReserved = "name_reserved"
+	RejectNameTaken    = "name_taken"
+)
+
+// joinRejection explains why a name was refused and offers one that would work.
+type joinRejection struct {
+	Reason     string
+	Message    string
+	Suggestion string
+}
+
+func (e *joinRejection) Error() string { return e.Message }
+
+// cleanName trims a name, drops control and formatting characters, collapses
+// inner whitespace and normalises it to NFC.
+func cleanName(raw string) string {
+	if !utf8.ValidString(raw) {
+		return ""
+	}
+	s := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, raw)
+	return norm.NFC.String(strings.Join(strings.Fields(s), " "))
+}
+
+// nameKey is the form names are compared in.
+func nameKey(name string) string {
+	return cases.Fold().String(norm.NFKC.String(name))
+}
+
+// validateName cleans raw and checks it against the players already in the
+// lobby. Callers must hold l.mu.
+func (l *Lobby) validateName(raw string) (string, error) {
+	name := cleanName(raw)
+	switch {
+	case name == "" && strings.TrimSpace(raw) != "":
+		return "", &joinRejection{Reason: RejectNameInvalid, Message: "name contains no usable characters"}
+	case name == "":
+		return "", &joinRejection{Reason: RejectNameRequired, Message: "name required"}
+	case !strings.ContainsFunc(name, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }):
+		return "", &joinRejection{Reason: RejectNameInvalid, Message: "name must contain a letter or digit"}
+	case utf8.RuneCountInString(name) > maxNameLen:
+		return "", &joinRejection{
+			Reason:     RejectNameTooLong,
+			Message:    fmt.Sprintf("name must be at most %d characters", maxNameLen),
+			Suggestion: l.freeName(truncateRunes(name, maxNameLen)),
+		}
+	case reservedNames[nameKey(name)]:
+		return "", &joinRejection{
+			Reason:     RejectNameReserved,
+			Message:    fmt.Sprintf("%q is reserved", name),
+			Suggestion: l.freeName(name),
+		}
+	case l.nameTaken(name):
+		return "", &joinRejection{
+			Reason:     RejectNameTaken,
+			Message:    fmt.Sprintf("%q is already taken in this lobby", name),
+			Suggestion: l.freeName(name),
+		}
+	}
+	return name, nil
+}
+
+// nameTaken reports whether a player already uses name, ignoring case.
+// Callers must hold l.mu.
+func (l *Lobby) nameTaken(name string) bool {
+	key := nameKey(name)
+	for _, p := range l.Players {
+		if nameKey(p) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// freeName returns name if it is usable, otherwise name with the lowest
+// number appended that is. Callers must hold l.mu.
+func (l *Lobby) freeName(name string) string {
+	if !reservedNames[nameKey(name)] && !l.nameTaken(name) {
+		return name
+	}
+	for n := 2; ; n++ {
+		suffix := " " + strconv.Itoa(n)
+		candidate := truncateRunes(name, maxNameLen-utf8.RuneCountInString(suffix)) + suffix
+		if !l.nameTaken(candidate) {
+			return candidate
+		}
+	}
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n]))
+}
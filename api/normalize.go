@@ -0,0 +1,99 @@
+package api
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// spellingVariants maps alternative spellings onto the form used in the word list.
+var spellingVariants = map[string]string{
+	"aeroplane": "airplane",
+	"aluminium": "aluminum",
+	"centre":    "center",
+	"colour":    "color",
+	"doughnut":  "donut",
+	"grey":      "gray",
+	"jewellery": "jewelry",
+	"moustache": "mustache",
+	"pyjama":    "pajama",
+	"theatre":   "theater",
+	"tyre":      "tire",
+	"whisky":    "whiskey",
+	"yoghurt":   "yogurt",
+}
+
+// wordForms folds case, punctuation, whitespace, plurals and common spelling
+// variants so "Hot-Dogs " and "hotdog" share a form. An ending can be read
+// more than one way, "cookies" as "cooky" or "cookie", so every reading is
+// returned.
+func wordForms(s string) []string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case r == '\'':
+			return -1
+		default:
+			return ' '
+		}
+	}, s)
+
+	forms := []string{""}
+	for _, f := range strings.Fields(s) {
+		var next []string
+		for _, prefix := range forms {
+			for _, sg := range singulars(f) {
+				next = append(next, prefix+sg)
+			}
+		}
+		forms = next
+	}
+	for i, f := range forms {
+		if v, ok := spellingVariants[f]; ok {
+			forms[i] = v
+		}
+	}
+	return forms
+}
+
+// singulars returns the readings of a single word with any English plural
+// ending stripped, most likely first: "strawberries" may be "strawberry" or
+// "strawberrie", "avalanches" "avalanch" or "avalanche", and "lens" "len" or
+// "lens". The results are only used for comparison, so "tomatoes", "tomato",
+// "shoe" and "shoes" all fold the trailing "oe" down to "o".
+func singulars(w string) []string {
+	var out []string
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		out = []string{w[:len(w)-3] + "y", w[:len(w)-1]}
+	case len(w) > 4 && (strings.HasSuffix(w, "ches") || strings.HasSuffix(w, "shes") ||
+		strings.HasSuffix(w, "ses") || strings.HasSuffix(w, "xes") || strings.HasSuffix(w, "zes")):
+		out = []string{w[:len(w)-2], w[:len(w)-1]}
+	case len(w) > 3 && (strings.HasSuffix(w, "us") || strings.HasSuffix(w, "is")):
+		// "cactus" and "tennis", but also "emus" and "taxis"
+		out = []string{w, w[:len(w)-1]}
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss"):
+		// "lens" and "canvas" take "es" in the plural
+		out = []string{w[:len(w)-1], w}
+	default:
+		out = []string{w}
+	}
+	for i, f := range out {
+		if len(f) > 2 && strings.HasSuffix(f, "oe") {
+			out[i] = f[:len(f)-1]
+		}
+	}
+	return out
+}
+
+// wordsMatch reports whether guess names the same word as word.
+func wordsMatch(guess, word string) bool {
+	forms := wordForms(word)
+	for _, g := range wordForms(guess) {
+		if g != "" && slices.Contains(forms, g) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"imposter/api/protocol"
+)
+
+// Round phases. Players give clues, discuss, vote, and see the vote revealed;
+// while there is no winner the round goes back to clues. The server owns the
+// clock: a phase with a duration moves on by itself when its deadline
+// passes, and the host can pause, resume or skip it.
+const (
+	PhaseClue       = "clue"
+	PhaseDiscussion = "discussion"
+	PhaseVoting     = "voting"
+	PhaseReveal     = "reveal"
+)
+
+// PhaseTimers are part of the wire protocol.
+type PhaseTimers = protocol.PhaseTimers
+
+// maxPhaseSeconds bounds each phase duration.
+const maxPhaseSeconds = 60 * 60
+
+func validateTimers(t PhaseTimers) error {
+	for _, s := range []int{t.Clue, t.Discussion, t.Voting, t.Reveal} {
+		if s < 0 || s > maxPhaseSeconds {
+			return fmt.Errorf("phase timers must be 0 to %d seconds", maxPhaseSeconds)
+		}
+	}
+	return nil
+}
+
+// phaseClock is the phase the round is in and its deadline.
+type phaseClock struct {
+	name      string
+	duration  time.Duration // 0 when the phase has no time limit
+	endsAt    time.Time     // zero when paused or unlimited
+	remaining time.Duration // time left while paused
+	paused    bool
+	timer     *time.Timer
+	seq       int // counts timers set, so a stale one can tell
+}
+
+var errNoPhase = errors.New("no round in progress")
+
+// phaseDuration is how long phase lasts under the lobby's timers. The
+// reveal that ends a round stays up until the host deals again.
+// Callers must hold l.mu.
+func (l *Lobby) phaseDuration(phase string) time.Duration {
+	var secs int
+	switch phase {
+	case PhaseClue:
+		secs = l.Timers.Clue
+	case PhaseDiscussion:
+		secs = l.Timers.Discussion
+	case PhaseVoting:
+		secs = l.Timers.Voting
+	case PhaseReveal:
+		if l.Winner == "" {
+			secs = l.Timers.Reveal
+		}
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// enterPhase moves the round to phase, starts its clock and tells everyone.
+// Callers must hold l.mu.
+func (m *LobbyManager) enterPhase(l *Lobby, phase string) {
+	l.stopPhase()
+	l.phase = &phaseClock{name: phase, duration: l.phaseDuration(phase)}
+	m.runPhase(l, l.phase.duration)
+	l.sendAll(l.phaseChanged())
+	m.logEvent(EventPhaseChanged, "code", l.Code, "phase", phase, "duration", seconds(l.phase.duration))
+}
+
+// runPhase starts the clock on the current phase with d left, if the phase
+// has a time limit. Callers must hold l.mu.
+func (m *LobbyManager) runPhase(l *Lobby, d time.Duration) {
+	p := l.phase
+	p.paused = false
+	p.remaining = 0
+	if p.duration == 0 {
+		return
+	}
+	p.endsAt = time.Now().Add(d)
+	p.seq++
+	seq := p.seq
+	p.timer = time.AfterFunc(d, func() { m.phaseTimeout(l, p, seq) })
+}
+
+// phaseTimeout moves the round on when timer seq of clock p runs out.
+func (m *LobbyManager) phaseTimeout(l *Lobby, p *phaseClock, seq int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the phase may have been skipped, paused or ended since the timer was set
+	if l.phase != p || p.paused || p.seq != seq {
+		return
+	}
+	m.advancePhase(l)
+	m.save(l)
+}
+
+// advancePhase ends the current phase the way the game would: the vote
+// opens when discussion ends, is tallied when voting ends, and a caught
+// imposter who runs out of time forfeits their guess. Callers must hold l.mu.
+func (m *LobbyManager) advancePhase(l *Lobby) {
+	switch l.phase.name {
+	case PhaseClue:
+		m.enterPhase(l, PhaseDiscussion)
+	case PhaseDiscussion:
+		if err := m.beginVote(l); err != nil {
+			// the round ended some other way
+			l.stopPhase()
+		}
+	case PhaseVoting:
+		if l.vote != nil {
+			m.resolveVote(l)
+		} else {
+			m.enterPhase(l, PhaseReveal)
+		}
+	case PhaseReveal:
+		if l.guesser != "" {
+			m.finishGuess(l, "", false)
+		}
+		if l.Winner == "" {
+			m.beginClues(l)
+		}
+	}
+}
+
+// stopPhase cancels the phase clock. Callers must hold l.mu.
+func (l *Lobby) stopPhase() {
+	if l.phase != nil && l.phase.timer != nil {
+		l.phase.timer.Stop()
+	}
+	l.phase = nil
+}
+
+// phaseChanged builds the phase_changed message for the current phase.
+// Callers must hold l.mu.
+func (l *Lobby) phaseChanged() *protocol.PhaseChanged {
+	p := l.phase
+	msg := &protocol.PhaseChanged{
+		Code:     l.Code,
+		Phase:    p.name,
+		Duration: int(p.duration.Seconds()),
+		Paused:   p.paused,
+	}
+	switch {
+	case p.paused:
+		msg.Remaining = int(p.remaining.Round(time.Second).Seconds())
+	case !p.endsAt.IsZero():
+		at := p.endsAt
+		msg.EndsAt = &at
+		msg.Remaining = int(time.Until(at).Round(time.Second).Seconds())
+	}
+	return msg
+}
+
+func (m *LobbyManager) pausePhase(l *Lobby) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p := l.phase
+	switch {
+	case p == nil:
+		return errNoPhase
+	case p.paused:
+		return errors.New("already paused")
+	case p.duration == 0:
+		return errors.New("this phase has no time limit")
+	}
+	p.timer.Stop()
+	p.timer = nil
+	p.remaining = max(time.Until(p.endsAt), 0)
+	p.endsAt = time.Time{}
+	p.paused = true
+	m.save(l)
+
+	l.sendAll(l.phaseChanged())
+	m.logEvent(EventPhasePaused, "code", l.Code, "phase", p.name, "remaining", seconds(p.remaining))
+	return nil
+}
+
+func (m *LobbyManager) resumePhase(l *Lobby) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p := l.phase
+	switch {
+	case p == nil:
+		return errNoPhase
+	case !p.paused:
+		return errors.New("not paused")
+	}
+	remaining := p.remaining
+	m.runPhase(l, remaining)
+	m.save(l)
+
+	l.sendAll(l.phaseChanged())
+	m.logEvent(EventPhaseResumed, "code", l.Code, "phase", p.name, "remaining", seconds(remaining))
+	return nil
+}
+
+func (m *LobbyManager) skipPhase(l *Lobby) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p := l.phase
+	switch {
+	case p == nil:
+		return errNoPhase
+	case p.name == PhaseReveal && l.Winner != "":
+		return errors.New("the round is over")
+	}
+	m.logEvent(EventPhaseSkipped, "code", l.Code, "phase", p.name)
+	m.advancePhase(l)
+	m.save(l)
+	return nil
+}
+
+// phaseSnapshot is the journalled phase clock. The clock restarts from
+// Remaining when the lobby is restored.
+type phaseSnapshot struct {
+	Name      string        `json:"name"`
+	Duration  time.Duration `json:"duration"`
+	Remaining time.Duration `json:"remaining"`
+	Paused    bool          `json:"paused"`
+}
+
+// phaseSnapshot captures the phase clock, nil if there is none.
+// Callers must hold l.mu.
+func (l *Lobby) phaseSnapshot() *phaseSnapshot {
+	p := l.phase
+	if p == nil {
+		return nil
+	}
+	remaining := p.remaining
+	if !p.endsAt.IsZero() {
+		remaining = max(time.Until(p.endsAt), 0)
+	}
+	return &phaseSnapshot{Name: p.name, Duration: p.duration, Remaining: remaining, Paused: p.paused}
+}
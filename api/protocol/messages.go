@@ -0,0 +1,429 @@
+package protocol
+
+import (
+	"errors"
+	"time"
+)
+
+// Message types sent by clients.
+const (
+	TypeJoin        = "join"
+	TypeLeave       = "leave"
+	TypeStart       = "start"
+	TypeVoteBad     = "vote_bad"
+	TypeOpenVote    = "open_vote"
+	TypeCastVote    = "cast_vote"
+	TypeCloseVote   = "close_vote"
+	TypeGuessWord   = "guess_word"
+	TypeSkipGuess   = "skip_guess"
+	TypeExtend      = "extend"
+	TypePausePhase  = "pause_phase"
+	TypeResumePhase = "resume_phase"
+	TypeSkipPhase   = "skip_phase"
+	TypeNextTurn    = "next_turn"
+	TypeSubmitClue  = "submit_clue"
+)
+
+// Message types sent by the server.
+const (
+	TypeHostReady        = "host_ready"
+	TypeLobbyState       = "lobby_state"
+	TypeJoinRejected     = "join_rejected"
+	TypeGameStarted      = "game_started"
+	TypeStartGame        = "start_game"
+	TypeWordVoteUpdate   = "word_vote_update"
+	TypeGameEnded        = "game_ended"
+	TypePlayerStatus     = "player_status"
+	TypeVoteOpened       = "vote_opened"
+	TypeVoteTally        = "vote_tally"
+	TypeVoteResult       = "vote_result"
+	TypeGuessResult      = "guess_result"
+	TypeScoreboard       = "scoreboard"
+	TypeServerRestarting = "server_restarting"
+	TypeLobbyExpiring    = "lobby_expiring"
+	TypeLobbyExtended    = "lobby_extended"
+	TypeLobbyClosed      = "lobby_closed"
+	TypePhaseChanged     = "phase_changed"
+	TypeSpeakingOrder    = "speaking_order"
+	TypeTurnChanged      = "turn_changed"
+	TypeClueBoard        = "clue_board"
+	TypeError            = "error"
+)
+
+// Join is the first message on a connection. A Token from an earlier join
+// resumes that identity; HostToken is required to join as the host.
+type Join struct {
+	Header
+	Name      string `json:"name"`
+	Token     string `json:"token,omitempty"`
+	HostToken string `json:"host_token,omitempty"`
+}
+
+// Leave gives up the player's seat instead of holding it for a reconnect.
+type Leave struct{ Header }
+
+// Start asks every player's client to move to the game screen.
+type Start struct{ Header }
+
+// VoteBad marks (or unmarks) the current word as a bad one.
+type VoteBad struct {
+	Header
+	Voted bool `json:"voted"`
+}
+
+// OpenVote starts an elimination vote. Host only.
+type OpenVote struct{ Header }
+
+// CastVote votes for the player the sender suspects.
+type CastVote struct {
+	Header
+	Suspect string `json:"suspect"`
+}
+
+func (m *CastVote) validate() error {
+	if m.Suspect == "" {
+		return errors.New("suspect required")
+	}
+	return nil
+}
+
+// CloseVote tallies the votes cast so far. Host only.
+type CloseVote struct{ Header }
+
+// GuessWord is a caught imposter's guess at the word.
+type GuessWord struct {
+	Header
+	Guess string `json:"guess"`
+}
+
+func (m *GuessWord) validate() error {
+	if m.Guess == "" {
+		return errors.New("guess required")
+	}
+	return nil
+}
+
+// SkipGuess gives up waiting for the caught imposter's guess. Host only.
+type SkipGuess struct{ Header }
+
+// Extend keeps an idle lobby open for another full expiry period. Host only.
+type Extend struct{ Header }
+
+// PausePhase stops the round phase clock. Host only.
+type PausePhase struct{ Header }
+
+// ResumePhase restarts a paused phase clock. Host only.
+type ResumePhase struct{ Header }
+
+// SkipPhase ends the current round phase early. Host only.
+type SkipPhase struct{ Header }
+
+// NextTurn passes the turn to the next speaker. Only the current speaker
+// or the host may send it.
+type NextTurn struct{ Header }
+
+// SubmitClue is the current speaker's clue, which also ends their turn.
+type SubmitClue struct {
+	Header
+	Clue string `json:"clue"`
+}
+
+func (m *SubmitClue) validate() error {
+	if m.Clue == "" {
+		return errors.New("clue required")
+	}
+	return nil
+}
+
+// HostReady confirms the host connection and carries its session token.
+type HostReady struct {
+	Header
+	Code  string `json:"code"`
+	Token string `json:"token"`
+}
+
+// LobbyState lists the players in the lobby. Token is the recipient's own
+// session token, for reconnecting.
+type LobbyState struct {
+	Header
+	Code    string   `json:"code"`
+	Players []string `json:"players"`
+	Away    []string `json:"away"` // players whose connection dropped
+	Token   string   `json:"token"`
+}
+
+// JoinRejected refuses a join and suggests a name that would be accepted.
+type JoinRejected struct {
+	Header
+	Reason     string `json:"reason"` // e.g. "name_taken", "name_reserved"
+	Message    string `json:"error"`
+	Suggestion string `json:"suggestion"`
+}
+
+// GameStarted deals a player their role and word. The host gets the
+// player Count instead.
+type GameStarted struct {
+	Header
+	Code     string `json:"code"`
+	Role     string `json:"role,omitempty"` // "imposter" or "word"
+	Word     string `json:"word,omitempty"`
+	Category string `json:"category,omitempty"`
+	Count    int    `json:"count,omitempty"`
+}
+
+// StartGame tells players' clients to move to the game screen.
+type StartGame struct{ Header }
+
+// WordVoteUpdate is the number of players who think the word is bad.
+type WordVoteUpdate struct {
+	Header
+	Code  string `json:"code"`
+	Count int    `json:"count"`
+}
+
+// GameEnded sends everyone back to the lobby.
+type GameEnded struct {
+	Header
+	Code string `json:"code"`
+}
+
+// PlayerStatus reports a player's connection coming or going.
+type PlayerStatus struct {
+	Header
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Status string `json:"status"` // "online", "away" or "gone"
+}
+
+// VoteOpened starts a vote between Candidates.
+type VoteOpened struct {
+	Header
+	Code       string   `json:"code"`
+	Candidates []string `json:"candidates"`
+	Revote     bool     `json:"revote"`
+}
+
+// VoteTally shows the host the running count of an open vote.
+type VoteTally struct {
+	Header
+	Code   string         `json:"code"`
+	Tally  map[string]int `json:"tally"`
+	Votes  int            `json:"votes"`
+	Voters int            `json:"voters"`
+}
+
+// VoteResult reveals who was voted out and whether the round is over.
+type VoteResult struct {
+	Header
+	Code         string         `json:"code"`
+	Tally        map[string]int `json:"tally"`
+	Tie          bool           `json:"tie"`
+	Eliminated   string         `json:"eliminated"` // "" when nobody was voted out
+	Role         string         `json:"role,omitempty"`
+	Winner       string         `json:"winner"`                  // "", "word", "imposters"
+	GuessPending bool           `json:"guess_pending,omitempty"` // the caught imposter may guess the word
+}
+
+// GuessResult reveals the word and whether the caught imposter guessed it.
+type GuessResult struct {
+	Header
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Guess   string `json:"guess"`
+	Correct bool   `json:"correct"`
+	Word    string `json:"word"`
+	Winner  string `json:"winner"`
+}
+
+// Scoreboard pushes the standings after each round.
+type Scoreboard struct {
+	Header
+	ScoreboardData
+}
+
+// ServerRestarting warns that the server is shutting down and expects to be
+// back within ETA seconds. The connection closes right after it; clients
+// reconnect with their session tokens.
+type ServerRestarting struct {
+	Header
+	Code string `json:"code"`
+	ETA  int    `json:"eta"` // seconds
+}
+
+// LobbyExpiring warns that an idle lobby will close at ExpiresAt unless
+// there is some activity or the host extends it.
+type LobbyExpiring struct {
+	Header
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ExpiresIn int64     `json:"expires_in"` // seconds
+}
+
+// LobbyExtended reports the host extending the lobby to ExpiresAt.
+type LobbyExtended struct {
+	Header
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ExpiresIn int64     `json:"expires_in"` // seconds
+}
+
+// LobbyClosed is the last message before the server closes the lobby.
+type LobbyClosed struct {
+	Header
+	Code   string `json:"code"`
+	Reason string `json:"reason"` // "expired"
+}
+
+// PhaseChanged reports the round moving to a new phase, or its clock being
+// paused or resumed. EndsAt is the server's deadline for the phase; it is
+// nil when the phase has no time limit or the clock is paused.
+type PhaseChanged struct {
+	Header
+	Code      string     `json:"code"`
+	Phase     string     `json:"phase"` // "clue", "discussion", "voting" or "reveal"
+	EndsAt    *time.Time `json:"ends_at"`
+	Duration  int        `json:"duration"`  // full length of the phase in seconds, 0 for no limit
+	Remaining int        `json:"remaining"` // seconds left when the message was sent
+	Paused    bool       `json:"paused"`
+}
+
+// SpeakingOrder is the order players give their clues in this round.
+// Turn is the index in Order of the player whose turn it is.
+type SpeakingOrder struct {
+	Header
+	Code  string   `json:"code"`
+	Order []string `json:"order"`
+	Turn  int      `json:"turn"`
+}
+
+// TurnChanged passes the turn to Speaker, at index Turn of the speaking
+// order. Speaker is "" once everyone has spoken.
+type TurnChanged struct {
+	Header
+	Code    string `json:"code"`
+	Speaker string `json:"speaker"`
+	Turn    int    `json:"turn"`
+}
+
+// ClueBoard lists the clues given so far in this pass around the table, in
+// speaking order.
+type ClueBoard struct {
+	Header
+	Code  string `json:"code"`
+	Pass  int    `json:"pass"`
+	Clues []Clue `json:"clues"`
+}
+
+// ErrorMessage reports a message the server could not accept.
+type ErrorMessage struct {
+	Header
+	Reason  string `json:"reason"`
+	Message string `json:"error"`
+}
+
+func (e *ErrorMessage) Error() string { return e.Message }
+
+func (*Join) MessageType() string             { return TypeJoin }
+func (*Leave) MessageType() string            { return TypeLeave }
+func (*Start) MessageType() string            { return TypeStart }
+func (*VoteBad) MessageType() string          { return TypeVoteBad }
+func (*OpenVote) MessageType() string         { return TypeOpenVote }
+func (*CastVote) MessageType() string         { return TypeCastVote }
+func (*CloseVote) MessageType() string        { return TypeCloseVote }
+func (*GuessWord) MessageType() string        { return TypeGuessWord }
+func (*SkipGuess) MessageType() string        { return TypeSkipGuess }
+func (*Extend) MessageType() string           { return TypeExtend }
+func (*PausePhase) MessageType() string       { return TypePausePhase }
+func (*ResumePhase) MessageType() string      { return TypeResumePhase }
+func (*SkipPhase) MessageType() string        { return TypeSkipPhase }
+func (*NextTurn) MessageType() string         { return TypeNextTurn }
+func (*SubmitClue) MessageType() string       { return TypeSubmitClue }
+func (*HostReady) MessageType() string        { return TypeHostReady }
+func (*LobbyState) MessageType() string       { return TypeLobbyState }
+func (*JoinRejected) MessageType() string     { return TypeJoinRejected }
+func (*GameStarted) MessageType() string      { return TypeGameStarted }
+func (*StartGame) MessageType() string        { return TypeStartGame }
+func (*WordVoteUpdate) MessageType() string   { return TypeWordVoteUpdate }
+func (*GameEnded) MessageType() string        { return TypeGameEnded }
+func (*PlayerStatus) MessageType() string     { return TypePlayerStatus }
+func (*VoteOpened) MessageType() string       { return TypeVoteOpened }
+func (*VoteTally) MessageType() string        { return TypeVoteTally }
+func (*VoteResult) MessageType() string       { return TypeVoteResult }
+func (*GuessResult) MessageType() string      { return TypeGuessResult }
+func (*Scoreboard) MessageType() string       { return TypeScoreboard }
+func (*ServerRestarting) MessageType() string { return TypeServerRestarting }
+func (*LobbyExpiring) MessageType() string    { return TypeLobbyExpiring }
+func (*LobbyExtended) MessageType() string    { return TypeLobbyExtended }
+func (*LobbyClosed) MessageType() string      { return TypeLobbyClosed }
+func (*PhaseChanged) MessageType() string     { return TypePhaseChanged }
+func (*SpeakingOrder) MessageType() string    { return TypeSpeakingOrder }
+func (*TurnChanged) MessageType() string      { return TypeTurnChanged }
+func (*ClueBoard) MessageType() string        { return TypeClueBoard }
+func (*ErrorMessage) MessageType() string     { return TypeError }
+
+// PointsScheme configures how many points each outcome is worth.
+type PointsScheme struct {
+	WordWin       int `json:"word_win"`       // each word player when the word players win
+	ImposterWin   int `json:"imposter_win"`   // each imposter when the imposters win
+	CorrectVote   int `json:"correct_vote"`   // each vote cast against an imposter
+	ImposterGuess int `json:"imposter_guess"` // a caught imposter who guesses the word
+}
+
+// PhaseTimers are the round phase durations in seconds. A phase with no
+// duration lasts until the game moves it on or the host skips it.
+type PhaseTimers struct {
+	Clue       int `json:"clue"`
+	Discussion int `json:"discussion"`
+	Voting     int `json:"voting"`
+	Reveal     int `json:"reveal"`
+}
+
+// TurnRules constrain who speaks first each round.
+type TurnRules struct {
+	NoImposterFirst bool `json:"no_imposter_first"`
+	NoRepeatFirst   bool `json:"no_repeat_first"` // never the same first speaker twice in a row
+}
+
+// VoteOutcome records a single resolved vote within a round.
+type VoteOutcome struct {
+	Eliminated string         `json:"eliminated"` // "" when nobody was voted out
+	Role       string         `json:"role,omitempty"`
+	Tally      map[string]int `json:"tally"`
+}
+
+// Clue is one player's clue. Pass counts the times round the table within
+// a round, from 1.
+type Clue struct {
+	Name string `json:"name"`
+	Clue string `json:"clue"`
+	Pass int    `json:"pass"`
+}
+
+// RoundRecord is the history entry kept for each round of a match.
+type RoundRecord struct {
+	Round     int            `json:"round"`
+	Word      string         `json:"word"`
+	Decoy     string         `json:"decoy,omitempty"` // undercover mode only
+	Imposters []string       `json:"imposters"`
+	Clues     []Clue         `json:"clues"`
+	Votes     []VoteOutcome  `json:"votes"`
+	Winner    string         `json:"winner"` // "", "word", "imposters"
+	Points    map[string]int `json:"points"`
+	StartedAt time.Time      `json:"started_at"`
+	EndedAt   time.Time      `json:"ended_at"`
+}
+
+// ScoreEntry is one player's match total.
+type ScoreEntry struct {
+	Name   string `json:"name"`
+	Points int    `json:"points"`
+}
+
+// ScoreboardData is the match standings, highest score first, and the
+// history of finished rounds.
+type ScoreboardData struct {
+	Code         string        `json:"code"`
+	Scores       []ScoreEntry  `json:"scores"`
+	Rounds       []RoundRecord `json:"rounds"`
+	PointsScheme PointsScheme  `json:"points_scheme"`
+}
@@ -0,0 +1,172 @@
+// Package protocol defines the messages exchanged over the lobby WebSocket.
+//
+// Every message is a JSON object carrying its type and the protocol version
+// it was written for:
+//
+//	{"type": "cast_vote", "v": 1, "suspect": "alice"}
+//
+// Clients ask for a version when they join (the "v" field of the join
+// message or a ?v= query parameter) and the server answers in the newest
+// version both sides understand. Clients that predate versioning send no
+// version and are treated as speaking MinVersion.
+package protocol
+
+//go:generate go run ./tsgen -out ../../ui/src/protocol.ts
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+const (
+	// Version is the newest protocol version the server speaks.
+	Version = 1
+	// MinVersion is the oldest protocol version the server still accepts.
+	MinVersion = 1
+)
+
+// Header is embedded in every message.
+type Header struct {
+	Type string `json:"type"`
+	V    int    `json:"v"`
+}
+
+func (h *Header) header() *Header { return h }
+
+// Message is implemented by every message type in this package.
+type Message interface {
+	MessageType() string
+	header() *Header
+}
+
+// Error reasons.
+const (
+	ReasonMalformed          = "malformed"           // not a JSON object, or fields of the wrong type
+	ReasonUnknownType        = "unknown_type"        // no such inbound message
+	ReasonUnsupportedVersion = "unsupported_version" // version outside MinVersion..Version
+	ReasonInvalid            = "invalid"             // well formed but missing required fields
+	ReasonJoinRequired       = "join_required"       // the first message must be a join
+	ReasonUnauthorized       = "unauthorized"        // bad or missing host token
+	ReasonForbidden          = "forbidden"           // not allowed for this player
+	ReasonRejected           = "rejected"            // refused by the game rules
+)
+
+// inboundMessages lists every message a client may send.
+var inboundMessages = []Message{
+	&Join{},
+	&Leave{},
+	&Start{},
+	&VoteBad{},
+	&OpenVote{},
+	&CastVote{},
+	&CloseVote{},
+	&GuessWord{},
+	&SkipGuess{},
+	&Extend{},
+	&PausePhase{},
+	&ResumePhase{},
+	&SkipPhase{},
+	&NextTurn{},
+	&SubmitClue{},
+}
+
+// outboundMessages lists every message the server sends.
+var outboundMessages = []Message{
+	&HostReady{},
+	&LobbyState{},
+	&JoinRejected{},
+	&GameStarted{},
+	&StartGame{},
+	&WordVoteUpdate{},
+	&GameEnded{},
+	&PlayerStatus{},
+	&VoteOpened{},
+	&VoteTally{},
+	&VoteResult{},
+	&GuessResult{},
+	&Scoreboard{},
+	&ServerRestarting{},
+	&LobbyExpiring{},
+	&LobbyExtended{},
+	&LobbyClosed{},
+	&PhaseChanged{},
+	&SpeakingOrder{},
+	&TurnChanged{},
+	&ClueBoard{},
+	&ErrorMessage{},
+}
+
+// inbound maps each inbound message type to its struct.
+var inbound = make(map[string]reflect.Type, len(inboundMessages))
+
+func init() {
+	for _, m := range inboundMessages {
+		inbound[m.MessageType()] = reflect.TypeOf(m).Elem()
+	}
+}
+
+// Inbound returns a zero value of every message a client may send.
+func Inbound() []Message { return append([]Message(nil), inboundMessages...) }
+
+// Outbound returns a zero value of every message the server sends.
+func Outbound() []Message { return append([]Message(nil), outboundMessages...) }
+
+// validator is implemented by inbound messages with required fields.
+type validator interface {
+	validate() error
+}
+
+// Decode parses an inbound message. Unknown types, malformed payloads and
+// unsupported versions are reported as an *ErrorMessage ready to send back.
+func Decode(data []byte) (Message, error) {
+	var h Header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, Errorf(ReasonMalformed, "malformed message: %v", err)
+	}
+	t, ok := inbound[h.Type]
+	if !ok {
+		return nil, Errorf(ReasonUnknownType, "unknown message type %q", h.Type)
+	}
+	if h.V != 0 && (h.V < MinVersion || h.V > Version) {
+		return nil, Errorf(ReasonUnsupportedVersion, "unsupported protocol version %d", h.V)
+	}
+
+	msg := reflect.New(t).Interface().(Message)
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, Errorf(ReasonMalformed, "malformed %s message: %v", h.Type, err)
+	}
+	if v, ok := msg.(validator); ok {
+		if err := v.validate(); err != nil {
+			return nil, Errorf(ReasonInvalid, "invalid %s message: %v", h.Type, err)
+		}
+	}
+	return msg, nil
+}
+
+// Encode stamps msg with its type and version and marshals it.
+func Encode(version int, msg Message) ([]byte, error) {
+	h := msg.header()
+	h.Type = msg.MessageType()
+	h.V = version
+	return json.Marshal(msg)
+}
+
+// Negotiate picks the version to speak with a client that asked for
+// requested. A request of 0 means the client predates versioning.
+func Negotiate(requested int) (int, error) {
+	switch {
+	case requested == 0:
+		return MinVersion, nil
+	case requested < MinVersion:
+		return 0, Errorf(ReasonUnsupportedVersion, "protocol version %d is no longer supported, need at least %d", requested, MinVersion)
+	case requested > Version:
+		return Version, nil
+	}
+	return requested, nil
+}
+
+// Errorf builds an Error message.
+func Errorf(reason, format string, args ...any) *ErrorMessage {
+	return &ErrorMessage{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
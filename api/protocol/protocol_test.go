@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestDecode tests that inbound messages decode into their structs
+func TestDecode(t *testing.T) {
+	msg, err := Decode([]byte(`{"type": "cast_vote", "v": 1, "suspect": "alice"}`))
+	if err != nil {
+		t.Fatal("failed to decode:", err)
+	}
+	vote, ok := msg.(*CastVote)
+	if !ok || vote.Suspect != "alice" || vote.V != 1 {
+		t.Fatalf("expected cast_vote for alice, got %#v", msg)
+	}
+
+	// clients from before versioning send no version
+	msg, err = Decode([]byte(`{"type": "vote_bad", "voted": true}`))
+	if err != nil {
+		t.Fatal("failed to decode unversioned message:", err)
+	}
+	if bad, ok := msg.(*VoteBad); !ok || !bad.Voted {
+		t.Fatalf("expected vote_bad, got %#v", msg)
+	}
+
+	t.Log("✓ Inbound messages decoded")
+}
+
+// TestDecodeErrors tests that bad messages are rejected with a typed error
+func TestDecodeErrors(t *testing.T) {
+	cases := []struct {
+		data, reason string
+	}{
+		{`not json`, ReasonMalformed},
+		{`["cast_vote"]`, ReasonMalformed},
+		{`{"type": "cast_vote", "suspect": 5}`, ReasonMalformed},
+		{`{"type": "vote_bad", "voted": "yes"}`, ReasonMalformed},
+		{`{"type": "teleport"}`, ReasonUnknownType},
+		{`{"suspect": "alice"}`, ReasonUnknownType},
+		{`{"type": "lobby_state"}`, ReasonUnknownType}, // outbound only
+		{`{"type": "leave", "v": 99}`, ReasonUnsupportedVersion},
+		{`{"type": "cast_vote", "suspect": ""}`, ReasonInvalid},
+		{`{"type": "guess_word"}`, ReasonInvalid},
+	}
+	for _, c := range cases {
+		_, err := Decode([]byte(c.data))
+		var perr *ErrorMessage
+		if !errors.As(err, &perr) || perr.Reason != c.reason {
+			t.Fatalf("decoding %s: expected %s error, got %v", c.data, c.reason, err)
+		}
+	}
+
+	t.Log("✓ Malformed and unknown messages rejected")
+}
+
+// TestEncode tests that outbound messages are stamped with type and version
+func TestEncode(t *testing.T) {
+	data, err := Encode(1, &ErrorMessage{Reason: ReasonRejected, Message: "no vote open"})
+	if err != nil {
+		t.Fatal("failed to encode:", err)
+	}
+	var got map[string]any
+	json.Unmarshal(data, &got)
+	if got["type"] != "error" || got["v"] != 1.0 || got["reason"] != "rejected" || got["error"] != "no vote open" {
+		t.Fatalf("unexpected encoding %s", data)
+	}
+
+	data, _ = Encode(1, &Scoreboard{ScoreboardData: ScoreboardData{Code: "abcd"}})
+	json.Unmarshal(data, &got)
+	if got["type"] != "scoreboard" || got["code"] != "abcd" {
+		t.Fatalf("expected flattened scoreboard, got %s", data)
+	}
+
+	t.Log("✓ Outbound messages encoded")
+}
+
+// TestNegotiate tests protocol version negotiation
+func TestNegotiate(t *testing.T) {
+	for requested, want := range map[int]int{0: MinVersion, 1: 1, Version + 5: Version} {
+		if got, err := Negotiate(requested); err != nil || got != want {
+			t.Fatalf("negotiating %d: expected %d, got %d (%v)", requested, want, got, err)
+		}
+	}
+	if _, err := Negotiate(-1); err == nil {
+		t.Fatal("expected versions below MinVersion to be refused")
+	}
+
+	t.Log("✓ Protocol version negotiated")
+}
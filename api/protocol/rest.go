@@ -0,0 +1,71 @@
+package protocol
+
+import "time"
+
+// Request and response bodies of the REST endpoints under /api/v1.
+
+// CreateLobbyResponse is returned by POST /lobbies. HostToken must be sent
+// with every host control request for the lobby.
+type CreateLobbyResponse struct {
+	Code      string `json:"code"`
+	HostToken string `json:"host_token"`
+}
+
+// LobbyInfo is returned by GET /lobbies/{code}.
+type LobbyInfo struct {
+	Code      string    `json:"code"`
+	Players   []string  `json:"players"`
+	ExpiresIn int64     `json:"expires_in"` // seconds
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StartGameRequest is the body of POST /lobbies/{code}/start and, optionally,
+// /restart. Omitted fields keep the lobby's current settings.
+type StartGameRequest struct {
+	Imposters int           `json:"imposters"`
+	Mode      string        `json:"mode,omitempty"`     // "classic" or "undercover"
+	Hint      string        `json:"hint,omitempty"`     // "off", "imposters" or "everyone"
+	TieRule   string        `json:"tie_rule,omitempty"` // "revote", "none" or "random"
+	Points    *PointsScheme `json:"points,omitempty"`
+	Timers    *PhaseTimers  `json:"timers,omitempty"`
+	Turns     *TurnRules    `json:"turns,omitempty"`
+	// BadWordThreshold is the percent of word players whose bad-word votes
+	// replace the word; 0 never replaces it
+	BadWordThreshold *int     `json:"bad_word_threshold,omitempty"`
+	Packs            []string `json:"packs,omitempty"`
+	// CustomWords draws from the lobby's uploaded list, mixed with Packs if given
+	CustomWords *bool `json:"custom_words,omitempty"`
+}
+
+// StatusResponse acknowledges a control request.
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+// WordPackInfo describes a word pack without its words, as listed by
+// GET /wordpacks.
+type WordPackInfo struct {
+	Name       string `json:"name"`
+	Language   string `json:"language"`
+	Category   string `json:"category"`
+	Difficulty string `json:"difficulty"` // "easy", "medium" or "hard"
+	WordCount  int    `json:"word_count"`
+	GroupCount int    `json:"group_count"`
+}
+
+// CustomWordsResponse is returned by GET /lobbies/{code}/words.
+type CustomWordsResponse struct {
+	Words []string `json:"words"`
+}
+
+// SetCustomWordsResponse is returned by PUT /lobbies/{code}/words.
+type SetCustomWordsResponse struct {
+	Count    int `json:"count"`
+	Rejected int `json:"rejected"` // words dropped as empty or too long
+}
+
+// WordHistoryResponse is returned by GET /lobbies/{code}/history once the
+// game has ended.
+type WordHistoryResponse struct {
+	Words []string `json:"words"` // every word dealt in the lobby, in order
+}
@@ -0,0 +1,259 @@
+// Command tsgen writes TypeScript definitions of the WebSocket messages and
+// REST bodies in package protocol, so the UI shares one source of truth with
+// the server. Run it with go generate from api/protocol.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"imposter/api/protocol"
+)
+
+// restTypes are the REST request and response bodies to emit, in order.
+var restTypes = []any{
+	protocol.CreateLobbyResponse{},
+	protocol.LobbyInfo{},
+	protocol.StartGameRequest{},
+	protocol.StatusResponse{},
+	protocol.WordPackInfo{},
+	protocol.CustomWordsResponse{},
+	protocol.SetCustomWordsResponse{},
+	protocol.WordHistoryResponse{},
+	protocol.ScoreboardData{},
+}
+
+func main() {
+	out := flag.String("out", "", "file to write (default stdout)")
+	src := flag.String("src", ".", "directory holding the protocol package source, for doc comments")
+	flag.Parse()
+
+	code, err := generate(*src)
+	if err != nil {
+		log.Fatalln("tsgen:", err)
+	}
+	if *out == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := os.WriteFile(*out, code, 0644); err != nil {
+		log.Fatalln("tsgen:", err)
+	}
+}
+
+// generate renders the TypeScript module.
+func generate(src string) ([]byte, error) {
+	docs, err := parseDocs(src)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{docs: docs, emitted: make(map[reflect.Type]bool)}
+
+	g.printf("// Code generated by tsgen from api/protocol. DO NOT EDIT.\n\n")
+	g.printf("export const PROTOCOL_VERSION = %d;\n", protocol.Version)
+	g.printf("export const MIN_PROTOCOL_VERSION = %d;\n", protocol.MinVersion)
+
+	g.printf("\n// WebSocket messages sent by clients.\n")
+	inbound := g.messages(protocol.Inbound())
+	g.printf("\n// WebSocket messages sent by the server.\n")
+	outbound := g.messages(protocol.Outbound())
+	g.printf("\nexport type InboundMessage = %s;\n", strings.Join(inbound, " | "))
+	g.printf("\nexport type OutboundMessage = %s;\n", strings.Join(outbound, " | "))
+
+	g.printf("\n// REST request and response bodies.\n")
+	for _, v := range restTypes {
+		g.enqueue(reflect.TypeOf(v))
+	}
+	g.drain()
+
+	return g.buf.Bytes(), nil
+}
+
+type generator struct {
+	buf     bytes.Buffer
+	docs    map[string]string // "Type" or "Type.Field" -> comment
+	emitted map[reflect.Type]bool
+	queue   []reflect.Type // named structs still to emit
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// messages emits an interface per message with its type as a literal, and
+// returns their names.
+func (g *generator) messages(msgs []protocol.Message) []string {
+	names := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		t := reflect.TypeOf(m).Elem()
+		g.emitted[t] = true
+		names = append(names, t.Name())
+		g.printf("\n")
+		g.interfaceOf(t, m.MessageType())
+	}
+	// types referenced by messages go right after them
+	g.drain()
+	return names
+}
+
+// drain emits every queued struct, including ones they reference.
+func (g *generator) drain() {
+	for len(g.queue) > 0 {
+		t := g.queue[0]
+		g.queue = g.queue[1:]
+		g.printf("\n")
+		g.interfaceOf(t, "")
+	}
+}
+
+func (g *generator) enqueue(t reflect.Type) {
+	if !g.emitted[t] {
+		g.emitted[t] = true
+		g.queue = append(g.queue, t)
+	}
+}
+
+// interfaceOf writes the interface for struct t. msgType, when set, narrows
+// the type field to that literal.
+func (g *generator) interfaceOf(t reflect.Type, msgType string) {
+	g.comment(g.docs[t.Name()], "")
+	g.printf("export interface %s {\n", t.Name())
+	g.fields(t, msgType)
+	g.printf("}\n")
+}
+
+func (g *generator) fields(t reflect.Type, msgType string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			// embedded structs are flattened, as encoding/json does
+			g.fields(f.Type, msgType)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		typ := g.tsType(f.Type)
+		if name == "type" && msgType != "" {
+			typ = fmt.Sprintf("%q", msgType)
+		}
+		optional := ""
+		if strings.Contains(opts, "omitempty") || f.Type.Kind() == reflect.Pointer {
+			optional = "?"
+		}
+		g.comment(g.docs[t.Name()+"."+f.Name], "  ")
+		g.printf("  %s%s: %s;\n", name, optional, typ)
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) tsType(t reflect.Type) string {
+	if t == timeType {
+		return "string" // RFC 3339
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.tsType(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return g.tsType(t.Elem()) + "[]"
+	case reflect.Map:
+		return fmt.Sprintf("Record<string, %s>", g.tsType(t.Elem()))
+	case reflect.Struct:
+		g.enqueue(t)
+		return t.Name()
+	}
+	return "unknown"
+}
+
+func (g *generator) comment(doc, indent string) {
+	if doc == "" {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(doc), "\n")
+	if len(lines) == 1 {
+		g.printf("%s/** %s */\n", indent, lines[0])
+		return
+	}
+	g.printf("%s/**\n", indent)
+	for _, l := range lines {
+		g.printf("%s * %s\n", indent, l)
+	}
+	g.printf("%s */\n", indent)
+}
+
+// parseDocs collects the doc comments of the types and fields declared in
+// the package source in dir.
+func parseDocs(dir string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]string)
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				docs[ts.Name.Name] = doc.Text()
+
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					text := field.Doc.Text()
+					if text == "" {
+						text = field.Comment.Text()
+					}
+					for _, n := range field.Names {
+						docs[ts.Name.Name+"."+n.Name] = text
+					}
+				}
+			}
+		}
+	}
+	return docs, nil
+}
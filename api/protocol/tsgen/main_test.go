@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestGeneratedUpToDate tests that ui/src/protocol.ts matches the Go definitions
+func TestGeneratedUpToDate(t *testing.T) {
+	want, err := generate("..")
+	if err != nil {
+		t.Fatal("failed to generate:", err)
+	}
+	got, err := os.ReadFile("../../../ui/src/protocol.ts")
+	if err != nil {
+		t.Fatal("failed to read generated file:", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("ui/src/protocol.ts is out of date, run go generate ./api/protocol")
+	}
+
+	t.Log("✓ TypeScript protocol types up to date")
+}
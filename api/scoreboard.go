@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"imposter/api/protocol"
+)
+
+// Match scoring. Every round is recorded on the lobby and its points are
+// added to per-name totals, so a player who reconnects under the same name
+// keeps their score.
+
+// The scoring types are part of the wire protocol.
+type (
+	PointsScheme = protocol.PointsScheme
+	VoteOutcome  = protocol.VoteOutcome
+	RoundRecord  = protocol.RoundRecord
+)
+
+var DefaultPoints = PointsScheme{
+	WordWin:       1,
+	ImposterWin:   2,
+	CorrectVote:   1,
+	ImposterGuess: 1,
+}
+
+func validatePoints(p PointsScheme) error {
+	if p.WordWin < 0 || p.ImposterWin < 0 || p.CorrectVote < 0 || p.ImposterGuess < 0 {
+		return errors.New("points must not be negative")
+	}
+	return nil
+}
+
+// beginRound opens the history entry for a freshly dealt round.
+// Callers must hold l.mu.
+func (l *Lobby) beginRound() {
+	imposters := []string{}
+	for _, p := range l.Players {
+		if l.PlayerRole[p] == "imposter" {
+			imposters = append(imposters, p)
+		}
+	}
+	l.round = &RoundRecord{
+		Round:     len(l.Rounds) + 1,
+		Word:      l.GameWord,
+		Decoy:     l.DecoyWord,
+		Imposters: imposters,
+		Clues:     []Clue{},
+		Votes:     []VoteOutcome{},
+		Points:    make(map[string]int),
+		StartedAt: time.Now(),
+	}
+}
+
+// recordVote adds a resolved vote to the current round and awards points to
+// everyone who voted for an imposter. Callers must hold l.mu.
+func (l *Lobby) recordVote(v *voteRound, eliminated string, tally map[string]int) {
+	if l.round == nil {
+		return
+	}
+	l.round.Votes = append(l.round.Votes, VoteOutcome{
+		Eliminated: eliminated,
+		Role:       l.PlayerRole[eliminated],
+		Tally:      tally,
+	})
+	for voter, suspect := range v.votes {
+		if l.PlayerRole[suspect] == "imposter" {
+			l.round.Points[voter] += l.Points.CorrectVote
+		}
+	}
+}
+
+// finishRound awards the win points, moves the current round into the match
+// history and pushes the scoreboard to everyone. It is a no-op when no round
+// is open. Callers must hold l.mu.
+func (m *LobbyManager) finishRound(l *Lobby) {
+	r := l.round
+	if r == nil {
+		return
+	}
+	l.round = nil
+
+	r.Winner = l.Winner
+	r.EndedAt = time.Now()
+	for p, role := range l.PlayerRole {
+		switch {
+		case r.Winner == "word" && role == "word":
+			r.Points[p] += l.Points.WordWin
+		case r.Winner == "imposters" && role == "imposter":
+			r.Points[p] += l.Points.ImposterWin
+		}
+	}
+
+	if l.Scores == nil {
+		l.Scores = make(map[string]int)
+	}
+	for p, pts := range r.Points {
+		l.Scores[p] += pts
+	}
+	l.Rounds = append(l.Rounds, *r)
+
+	m.logEvent(EventRoundFinished, "code", l.Code, "round", r.Round, "winner", r.Winner,
+		"players", len(l.Players), "imposters", len(r.Imposters), "duration", seconds(r.EndedAt.Sub(r.StartedAt)))
+
+	l.sendAll(&protocol.Scoreboard{ScoreboardData: l.scoreboard()})
+}
+
+// scoreboard builds the current standings, highest score first. Players in
+// the lobby with no points yet are listed with zero. Callers must hold l.mu.
+func (l *Lobby) scoreboard() protocol.ScoreboardData {
+	totals := make(map[string]int, len(l.Scores))
+	for p, pts := range l.Scores {
+		totals[p] = pts
+	}
+	for _, p := range l.Players {
+		totals[p] += 0
+	}
+
+	scores := make([]protocol.ScoreEntry, 0, len(totals))
+	for p, pts := range totals {
+		scores = append(scores, protocol.ScoreEntry{Name: p, Points: pts})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Points != scores[j].Points {
+			return scores[i].Points > scores[j].Points
+		}
+		return scores[i].Name < scores[j].Name
+	})
+
+	return protocol.ScoreboardData{
+		Code:         l.Code,
+		Scores:       scores,
+		Rounds:       append([]RoundRecord{}, l.Rounds...),
+		PointsScheme: l.Points,
+	}
+}
+
+// GetScoreboard returns the match standings and round history for a lobby
+func (m *LobbyManager) GetScoreboard(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	l, ok := m.store.Get(code)
+	if !ok {
+		http.Error(w, "lobby not found", http.StatusNotFound)
+		return
+	}
+
+	l.mu.Lock()
+	resp := l.scoreboard()
+	l.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
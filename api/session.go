@@ -0,0 +1,111 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"imposter/api/protocol"
+)
+
+// Session tokens let a player (or the host) refresh the page without losing
+// their seat. Every join is issued a token; when a socket drops the seat is
+// kept for the manager's reconnect grace period, and reconnecting with the
+// token resumes the same name, role and word.
+
+// defaultReconnectGrace is how long a dropped player's seat stays reserved.
+const defaultReconnectGrace = 30 * time.Second
+
+// Player connection statuses, broadcast as player_status.
+const (
+	StatusOnline = "online" // connected
+	StatusAway   = "away"   // dropped, seat held for the grace period
+	StatusGone   = "gone"   // left or never came back
+)
+
+func newSessionToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// tokenFor returns the session token issued to name. Callers must hold l.mu.
+func (l *Lobby) tokenFor(name string) string {
+	for token, n := range l.Sessions {
+		if n == name {
+			return token
+		}
+	}
+	return ""
+}
+
+// connected reports whether name has an open player socket.
+// Callers must hold l.mu.
+func (l *Lobby) connected(name string) bool {
+	for c := range l.clients {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// reserveSeat keeps a disconnected player's seat for the grace period before
+// releasing it. Callers must hold l.mu.
+func (m *LobbyManager) reserveSeat(l *Lobby, name string) {
+	if t, ok := l.leaving[name]; ok {
+		t.Stop()
+	}
+	l.leaving[name] = time.AfterFunc(m.reconnectGrace, func() {
+		m.releaseSeat(l, name)
+	})
+	l.sendStatus(name, StatusAway)
+}
+
+// releaseSeat removes a player whose grace period ran out without a reconnect.
+func (m *LobbyManager) releaseSeat(l *Lobby, name string) {
+	l.mu.Lock()
+	delete(l.leaving, name)
+	if l.connected(name) {
+		l.mu.Unlock()
+		return
+	}
+	l.removePlayer(name)
+	l.sendStatus(name, StatusGone)
+	m.save(l)
+	l.mu.Unlock()
+
+	m.logEvent(EventPlayerLeft, "code", l.Code, "player", name, "reason", "timeout")
+	m.broadcastLobby(l)
+}
+
+// sendStatus tells everyone that name's connection status changed.
+// Callers must hold l.mu.
+func (l *Lobby) sendStatus(name, status string) {
+	l.sendAll(&protocol.PlayerStatus{Code: l.Code, Name: name, Status: status})
+}
+
+// removePlayer drops name from the player list and revokes their session
+// tokens, so an old token can't take back a name someone else has since
+// joined with. Callers must hold l.mu.
+func (l *Lobby) removePlayer(name string) {
+	for i, p := range l.Players {
+		if p == name {
+			l.Players = append(l.Players[:i], l.Players[i+1:]...)
+			break
+		}
+	}
+	for token, n := range l.Sessions {
+		if n == name {
+			delete(l.Sessions, token)
+		}
+	}
+}
+
+// stopSeatTimers cancels every pending seat release. Callers must hold l.mu.
+func (l *Lobby) stopSeatTimers() {
+	for name, t := range l.leaving {
+		t.Stop()
+		delete(l.leaving, name)
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"imposter/api/protocol"
+)
+
+var errShuttingDown = errors.New("server is shutting down")
+
+// Shutdown winds the manager down for a server restart. It stops accepting
+// lobbies and connections, tells every client to expect the server back
+// within eta, saves each lobby and closes the sockets with the "service
+// restart" close code. Seats are not released, so players can pick up where
+// they left off if the lobbies are kept in a durable store. Shutdown returns
+// once every socket is closed and the store is flushed, or when ctx is done.
+func (m *LobbyManager) Shutdown(ctx context.Context, eta time.Duration) error {
+	if !m.closing.CompareAndSwap(false, true) {
+		return errShuttingDown
+	}
+	close(m.stop)
+	<-m.cleanupDone
+
+	var clients []*client
+	for _, l := range m.store.List() {
+		l.mu.Lock()
+		l.stopSeatTimers()
+		l.sendAll(&protocol.ServerRestarting{Code: l.Code, ETA: int(eta.Seconds())})
+		for c := range l.clients {
+			clients = append(clients, c)
+		}
+		if l.host != nil {
+			clients = append(clients, l.host)
+		}
+		m.save(l)
+		l.stopPhase()
+		l.mu.Unlock()
+	}
+	m.logEvent(EventShutdown, "connections", len(clients), "eta", seconds(eta))
+
+	for _, c := range clients {
+		c.closeWith(websocket.CloseServiceRestart)
+	}
+	var err error
+wait:
+	for _, c := range clients {
+		select {
+		case <-c.stopped:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break wait
+		}
+	}
+
+	if s, ok := m.store.(io.Closer); ok {
+		if cerr := s.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	if m.eventLog != nil {
+		if cerr := m.eventLog.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Run is the "imposter stats" command: it reads the logs named by -log and
+// any further arguments and writes the report to stdout.
+func Run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("imposter stats", flag.ContinueOnError)
+	var logs logList
+	fs.Var(&logs, "log", "event log to read, may be repeated (default lobbies.log)")
+	format := fs.String("format", "table", "output format: table or json")
+	expiry := fs.Duration("expiry", 15*time.Minute, "how long a lobby is assumed to stay open after its last event")
+	top := fs.Int("top", 10, "most voted bad words to list, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	logs = append(logs, fs.Args()...)
+	if len(logs) == 0 {
+		logs = logList{"lobbies.log"}
+	}
+	if *format != "table" && *format != "json" {
+		return errors.New("format must be table or json")
+	}
+
+	var events []Event
+	skipped := 0
+	for _, path := range logs {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		evs, n, err := Parse(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		events = append(events, evs...)
+		skipped += n
+	}
+
+	r := Build(events, Options{Expiry: *expiry, TopWords: *top})
+	r.SkippedLines = skipped
+	if *format == "json" {
+		return r.WriteJSON(stdout)
+	}
+	return r.WriteTable(stdout)
+}
+
+// logList is a repeatable flag.
+type logList []string
+
+func (l *logList) String() string { return strings.Join(*l, ",") }
+
+func (l *logList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
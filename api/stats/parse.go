@@ -0,0 +1,179 @@
+// Package stats reads the lobby event log, in both the structured JSON-lines
+// format and the free-text format written before it, and reports on how the
+// game is played.
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is one line of the event log, in a form shared by both formats.
+// Fields the line didn't carry are left zero.
+type Event struct {
+	Time       time.Time
+	Name       string // event name, e.g. "player_joined"
+	Code       string // lobby code
+	Player     string
+	Players    int // players in the lobby
+	Imposters  int
+	Word       string
+	WordID     string // stands in for Word where that is redacted
+	Restart    bool   // game_started for a restarted game
+	Bad        bool   // word_vote marking the word as bad
+	Votes      int    // word_vote: players currently voting the word bad
+	WordVotes  int    // word_vote: word players among them, or all of them in older logs
+	Duration   time.Duration
+	Winner     string
+	Structured bool // read from a JSON line
+}
+
+// Event names, matching those the server writes.
+const (
+	lobbyCreated   = "lobby_created"
+	lobbyExpired   = "lobby_expired"
+	playerJoined   = "player_joined"
+	playerLeft     = "player_left"
+	gameStarted    = "game_started"
+	gameEnded      = "game_ended"
+	roundFinished  = "round_finished"
+	wordVote       = "word_vote"
+	redactedSecret = "[redacted]"
+)
+
+// legacyTime is the timestamp format of the free-text log, in local time.
+const legacyTime = "2006-01-02 15:04:05"
+
+// legacyLine splits "[2025-12-19 09:59:30] Lobby created: frpwiy".
+var legacyLine = regexp.MustCompile(`^\[(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d)\] (.*)$`)
+
+// legacyEvents map the free-text messages to events. Submatches are named
+// after the Event fields they fill.
+var legacyEvents = []struct {
+	name    string
+	restart bool
+	re      *regexp.Regexp
+}{
+	{lobbyCreated, false, regexp.MustCompile(`^Lobby created: (?P<code>\w+)$`)},
+	{lobbyExpired, false, regexp.MustCompile(`^Lobby expired and removed: (?P<code>\w+)$`)},
+	{playerJoined, false, regexp.MustCompile(`^Player joined lobby (?P<code>\w+): (?P<player>.*) \(total players: (?P<players>\d+)\)$`)},
+	{playerLeft, false, regexp.MustCompile(`^Player left lobby (?P<code>\w+): (?P<player>.*)$`)},
+	{gameStarted, false, regexp.MustCompile(`^Game started in lobby (?P<code>\w+) with word '(?P<word>.*)' and (?P<imposters>\d+) imposters$`)},
+	{gameStarted, true, regexp.MustCompile(`^Game restarted in lobby (?P<code>\w+) with word '(?P<word>.*)' and (?P<imposters>\d+) imposters$`)},
+	{gameEnded, false, regexp.MustCompile(`^Game ended in lobby (?P<code>\w+)$`)},
+	{roundFinished, false, regexp.MustCompile(`^Round \d+ finished in lobby (?P<code>\w+), winner '(?P<winner>.*)'$`)},
+}
+
+// Parse reads every event in r. Lines in neither format, and legacy lines
+// for events the report doesn't use, are counted in skipped.
+func Parse(r io.Reader) (events []Event, skipped int, err error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		e, ok := parseLine(line)
+		if !ok {
+			skipped++
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, skipped, sc.Err()
+}
+
+func parseLine(line string) (Event, bool) {
+	if strings.HasPrefix(line, "{") {
+		return parseJSON(line)
+	}
+	return parseLegacy(line)
+}
+
+// jsonEvent is a structured log line. Durations are in seconds.
+type jsonEvent struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Code      string    `json:"code"`
+	Player    string    `json:"player"`
+	Players   int       `json:"players"`
+	Imposters int       `json:"imposters"`
+	Word      string    `json:"word"`
+	WordID    string    `json:"word_id"`
+	Restart   bool      `json:"restart"`
+	Bad       bool      `json:"bad"`
+	Votes     int       `json:"votes"`
+	WordVotes *int      `json:"word_votes"`
+	Duration  float64   `json:"duration"`
+	Winner    string    `json:"winner"`
+}
+
+func parseJSON(line string) (Event, bool) {
+	var j jsonEvent
+	if err := json.Unmarshal([]byte(line), &j); err != nil || j.Event == "" {
+		return Event{}, false
+	}
+	wordVotes := j.Votes
+	if j.WordVotes != nil {
+		wordVotes = *j.WordVotes
+	}
+	return Event{
+		Time:       j.Time,
+		Name:       j.Event,
+		Code:       j.Code,
+		Player:     j.Player,
+		Players:    j.Players,
+		Imposters:  j.Imposters,
+		Word:       j.Word,
+		WordID:     j.WordID,
+		Restart:    j.Restart,
+		Bad:        j.Bad,
+		Votes:      j.Votes,
+		WordVotes:  wordVotes,
+		Duration:   time.Duration(j.Duration * float64(time.Second)),
+		Winner:     j.Winner,
+		Structured: true,
+	}, true
+}
+
+func parseLegacy(line string) (Event, bool) {
+	m := legacyLine.FindStringSubmatch(line)
+	if m == nil {
+		return Event{}, false
+	}
+	t, err := time.ParseInLocation(legacyTime, m[1], time.Local)
+	if err != nil {
+		return Event{}, false
+	}
+	for _, le := range legacyEvents {
+		sub := le.re.FindStringSubmatch(m[2])
+		if sub == nil {
+			continue
+		}
+		e := Event{Time: t, Name: le.name, Restart: le.restart}
+		for i, field := range le.re.SubexpNames() {
+			switch field {
+			case "code":
+				e.Code = sub[i]
+			case "player":
+				e.Player = sub[i]
+			case "players":
+				e.Players, _ = strconv.Atoi(sub[i])
+			case "imposters":
+				e.Imposters, _ = strconv.Atoi(sub[i])
+			case "word":
+				e.Word = sub[i]
+			case "winner":
+				e.Winner = sub[i]
+			}
+		}
+		return e, true
+	}
+	return Event{}, false
+}
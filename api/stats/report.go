@@ -0,0 +1,366 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Report summarises an event log.
+type Report struct {
+	From             time.Time  `json:"from"`
+	To               time.Time  `json:"to"`
+	Lobbies          int        `json:"lobbies"`
+	LobbiesWithGames int        `json:"lobbies_with_games"`
+	Games            int        `json:"games"` // including restarts
+	AvgPlayers       float64    `json:"avg_players_per_lobby"`
+	AvgGamePlayers   float64    `json:"avg_players_per_game"`
+	AvgGameDuration  float64    `json:"avg_game_duration"` // seconds, over games that finished
+	ImposterRatio    float64    `json:"imposter_ratio"`    // imposters per player, averaged over games
+	BadVoteRate      float64    `json:"bad_vote_rate"`     // share of word players voting the word bad
+	BadVoteGames     int        `json:"bad_vote_games"`    // games BadVoteRate is taken over
+	PeakLobbies      int        `json:"peak_lobbies"`      // most lobbies open at once
+	PeakAt           time.Time  `json:"peak_at"`
+	Days             []DayStats `json:"days"`
+	Words            []WordStat `json:"words"` // most voted bad first
+	SkippedLines     int        `json:"skipped_lines"`
+}
+
+// DayStats counts lobbies and games started on one day.
+type DayStats struct {
+	Date    string `json:"date"` // YYYY-MM-DD, local time
+	Lobbies int    `json:"lobbies"`
+	Games   int    `json:"games"`
+}
+
+// WordStat is how often one word was voted bad.
+type WordStat struct {
+	Word        string  `json:"word"`              // the word ID where the log redacts words
+	ID          string  `json:"word_id,omitempty"` // ids change each time the server restarts
+	Games       int     `json:"games"`
+	BadVoteRate float64 `json:"bad_vote_rate"`
+}
+
+// Options tune how a log is read.
+type Options struct {
+	// Expiry is how long a lobby is assumed to stay open after its last
+	// event when the log doesn't say it expired.
+	Expiry time.Duration
+	// TopWords limits Report.Words.
+	TopWords int
+}
+
+type lobby struct {
+	open, close time.Time
+	expired     bool
+	players     int // current player count
+	maxPlayers  int
+	games       int
+	game        *game
+}
+
+type game struct {
+	start     time.Time
+	word      string
+	wordID    string
+	players   int
+	imposters int
+	badVotes  int
+	votesSeen bool // the log records bad-word votes for this game
+}
+
+// Build computes the report for events, which need not be in order.
+func Build(events []Event, opts Options) *Report {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+
+	r := &Report{Days: []DayStats{}, Words: []WordStat{}}
+	if len(events) > 0 {
+		r.From, r.To = events[0].Time, events[len(events)-1].Time
+	}
+
+	b := &builder{
+		report:  r,
+		lobbies: map[string]*lobby{},
+		days:    map[string]*DayStats{},
+		words:   map[string]*wordAcc{},
+	}
+	for _, e := range events {
+		b.add(e)
+	}
+	for _, l := range b.all {
+		if l.game != nil {
+			b.finishGame(l, time.Time{})
+		}
+	}
+	b.summarise(opts)
+	return r
+}
+
+type wordAcc struct {
+	word  string
+	games int
+	rates float64
+}
+
+type builder struct {
+	report  *Report
+	lobbies map[string]*lobby // by code, the latest lobby with it
+	all     []*lobby
+	days    map[string]*DayStats
+
+	players, gamePlayers, gamesWithPlayers int
+	durations                              time.Duration
+	finished                               int
+	ratios                                 float64
+	badRates                               float64
+	words                                  map[string]*wordAcc
+}
+
+func (b *builder) day(t time.Time) *DayStats {
+	date := t.Local().Format("2006-01-02")
+	d, ok := b.days[date]
+	if !ok {
+		d = &DayStats{Date: date}
+		b.days[date] = d
+	}
+	return d
+}
+
+func (b *builder) lobby(e Event) *lobby {
+	l, ok := b.lobbies[e.Code]
+	if !ok || e.Name == lobbyCreated {
+		// the log may start part way through a lobby
+		l = &lobby{open: e.Time}
+		b.lobbies[e.Code] = l
+		b.all = append(b.all, l)
+		b.day(e.Time).Lobbies++
+	}
+	if !l.expired {
+		l.close = e.Time
+	}
+	return l
+}
+
+func (b *builder) add(e Event) {
+	if e.Code == "" {
+		return
+	}
+	l := b.lobby(e)
+	switch e.Name {
+	case playerJoined:
+		l.players++
+		if e.Players > 0 {
+			l.players = e.Players
+		}
+		l.maxPlayers = max(l.maxPlayers, l.players)
+	case playerLeft:
+		l.players = max(l.players-1, 0)
+	case gameStarted:
+		if l.game != nil {
+			b.finishGame(l, e.Time)
+		}
+		players := e.Players
+		if players == 0 {
+			players = l.players
+		}
+		l.game = &game{start: e.Time, word: e.Word, wordID: e.WordID, players: players, imposters: e.Imposters, votesSeen: e.Structured}
+		l.games++
+		b.report.Games++
+		b.day(e.Time).Games++
+	case wordVote:
+		if l.game != nil {
+			l.game.badVotes = e.WordVotes
+		}
+	case roundFinished, gameEnded:
+		if l.game != nil {
+			end := e.Time
+			if e.Duration > 0 {
+				end = l.game.start.Add(e.Duration)
+			}
+			b.finishGame(l, end)
+		}
+	case lobbyExpired:
+		if l.game != nil {
+			b.finishGame(l, e.Time)
+		}
+		l.expired = true
+		l.close = e.Time
+	}
+}
+
+// finishGame accounts for the lobby's current game, which ended at end, or
+// never if end is zero.
+func (b *builder) finishGame(l *lobby, end time.Time) {
+	g := l.game
+	l.game = nil
+	if !end.IsZero() {
+		b.durations += end.Sub(g.start)
+		b.finished++
+	}
+	if g.players == 0 {
+		return
+	}
+	b.gamePlayers += g.players
+	b.gamesWithPlayers++
+	b.ratios += float64(g.imposters) / float64(g.players)
+
+	wordPlayers := g.players - g.imposters
+	if !g.votesSeen || wordPlayers <= 0 {
+		return
+	}
+	rate := float64(g.badVotes) / float64(wordPlayers)
+	b.badRates += rate
+	b.report.BadVoteGames++
+	word := g.word
+	if word == redactedSecret {
+		word = ""
+	}
+	key := g.wordID
+	if key == "" {
+		key = word
+	}
+	if key != "" {
+		w, ok := b.words[key]
+		if !ok {
+			w = &wordAcc{}
+			b.words[key] = w
+		}
+		if word != "" {
+			w.word = word
+		}
+		w.games++
+		w.rates += rate
+	}
+}
+
+func (b *builder) summarise(opts Options) {
+	r := b.report
+	r.Lobbies = len(b.all)
+
+	playing := 0
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	for _, l := range b.all {
+		if l.games > 0 {
+			r.LobbiesWithGames++
+		}
+		if l.maxPlayers > 0 {
+			b.players += l.maxPlayers
+			playing++
+		}
+		close := l.close
+		if !l.expired {
+			close = close.Add(opts.Expiry)
+		}
+		edges = append(edges, edge{l.open, 1}, edge{close, -1})
+	}
+	r.AvgPlayers = ratio(float64(b.players), playing)
+	r.AvgGamePlayers = ratio(float64(b.gamePlayers), b.gamesWithPlayers)
+	r.AvgGameDuration = ratio(b.durations.Seconds(), b.finished)
+	r.ImposterRatio = ratio(b.ratios, b.gamesWithPlayers)
+	r.BadVoteRate = ratio(b.badRates, r.BadVoteGames)
+
+	// closes sort before opens at the same instant
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].at.Before(edges[j].at)
+	})
+	open := 0
+	for _, e := range edges {
+		open += e.delta
+		if open > r.PeakLobbies {
+			r.PeakLobbies, r.PeakAt = open, e.at
+		}
+	}
+
+	for _, d := range b.days {
+		r.Days = append(r.Days, *d)
+	}
+	sort.Slice(r.Days, func(i, j int) bool { return r.Days[i].Date < r.Days[j].Date })
+
+	for key, w := range b.words {
+		ws := WordStat{Word: w.word, Games: w.games, BadVoteRate: w.rates / float64(w.games)}
+		if ws.Word == "" {
+			ws.Word = key
+		}
+		if key != w.word {
+			ws.ID = key
+		}
+		r.Words = append(r.Words, ws)
+	}
+	sort.Slice(r.Words, func(i, j int) bool {
+		a, b := r.Words[i], r.Words[j]
+		if a.BadVoteRate != b.BadVoteRate {
+			return a.BadVoteRate > b.BadVoteRate
+		}
+		if a.Games != b.Games {
+			return a.Games > b.Games
+		}
+		return a.Word < b.Word
+	})
+	if opts.TopWords > 0 && len(r.Words) > opts.TopWords {
+		r.Words = r.Words[:opts.TopWords]
+	}
+}
+
+func ratio(sum float64, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// WriteJSON writes r as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteTable writes r as aligned text tables.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	const stamp = "2006-01-02 15:04"
+	if !r.From.IsZero() {
+		fmt.Fprintf(tw, "Period\t%s to %s\n", r.From.Local().Format(stamp), r.To.Local().Format(stamp))
+	}
+	fmt.Fprintf(tw, "Lobbies\t%d (%d with games)\n", r.Lobbies, r.LobbiesWithGames)
+	fmt.Fprintf(tw, "Games\t%d\n", r.Games)
+	fmt.Fprintf(tw, "Avg players per lobby\t%.1f\n", r.AvgPlayers)
+	fmt.Fprintf(tw, "Avg players per game\t%.1f\n", r.AvgGamePlayers)
+	fmt.Fprintf(tw, "Avg game duration\t%s\n", time.Duration(r.AvgGameDuration*float64(time.Second)).Round(time.Second))
+	fmt.Fprintf(tw, "Imposter ratio\t%.2f\n", r.ImposterRatio)
+	if r.BadVoteGames > 0 {
+		fmt.Fprintf(tw, "Bad word vote rate\t%.0f%% (%d games)\n", r.BadVoteRate*100, r.BadVoteGames)
+	} else {
+		fmt.Fprintf(tw, "Bad word vote rate\tn/a\n")
+	}
+	if r.PeakLobbies > 0 {
+		fmt.Fprintf(tw, "Peak concurrent lobbies\t%d at %s\n", r.PeakLobbies, r.PeakAt.Local().Format(stamp))
+	}
+	if r.SkippedLines > 0 {
+		fmt.Fprintf(tw, "Skipped lines\t%d\n", r.SkippedLines)
+	}
+
+	if len(r.Days) > 0 {
+		fmt.Fprintf(tw, "\nDATE\tLOBBIES\tGAMES\n")
+		for _, d := range r.Days {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", d.Date, d.Lobbies, d.Games)
+		}
+	}
+	if len(r.Words) > 0 {
+		fmt.Fprintf(tw, "\nWORD\tGAMES\tVOTED BAD\n")
+		for _, ws := range r.Words {
+			fmt.Fprintf(tw, "%s\t%d\t%.0f%%\n", ws.Word, ws.Games, ws.BadVoteRate*100)
+		}
+	}
+	return tw.Flush()
+}
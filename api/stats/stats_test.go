@@ -0,0 +1,164 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const legacyLog = `[2025-12-19 19:00:00] Lobby created: abcd
+[2025-12-19 19:00:05] Host connected to lobby abcd
+[2025-12-19 19:00:10] Player joined lobby abcd: Sam (total players: 1)
+[2025-12-19 19:00:20] Player joined lobby abcd: Alex (total players: 2)
+[2025-12-19 19:00:30] Player joined lobby abcd: Kim (total players: 3)
+[2025-12-19 19:01:00] Game started in lobby abcd with word 'apple' and 1 imposters
+[2025-12-19 19:05:00] Game restarted in lobby abcd with word 'pear' and 1 imposters
+[2025-12-19 19:08:00] Game ended in lobby abcd
+[2025-12-19 19:02:00] Lobby created: efgh
+[2025-12-19 19:02:10] Player joined lobby efgh: Jo (total players: 1)
+not a log line
+`
+
+const jsonLog = `{"time":"2025-12-20T20:00:00Z","level":"INFO","event":"lobby_created","code":"wxyz"}
+{"time":"2025-12-20T20:00:10Z","level":"INFO","event":"player_joined","code":"wxyz","player":"A","players":1}
+{"time":"2025-12-20T20:00:11Z","level":"INFO","event":"player_joined","code":"wxyz","player":"B","players":2}
+{"time":"2025-12-20T20:00:12Z","level":"INFO","event":"player_joined","code":"wxyz","player":"C","players":3}
+{"time":"2025-12-20T20:00:13Z","level":"INFO","event":"player_joined","code":"wxyz","player":"D","players":4}
+{"time":"2025-12-20T20:00:14Z","level":"INFO","event":"player_joined","code":"wxyz","player":"E","players":5}
+{"time":"2025-12-20T20:01:00Z","level":"INFO","event":"game_started","code":"wxyz","restart":false,"players":5,"imposters":1,"word":"tea"}
+{"time":"2025-12-20T20:01:30Z","level":"INFO","event":"word_vote","code":"wxyz","player":"A","bad":true,"votes":1,"players":5,"word":"tea"}
+{"time":"2025-12-20T20:01:40Z","level":"INFO","event":"word_vote","code":"wxyz","player":"B","bad":true,"votes":2,"players":5,"word":"tea"}
+{"time":"2025-12-20T20:04:00Z","level":"INFO","event":"round_finished","code":"wxyz","round":1,"winner":"players","players":5,"imposters":1,"duration":180}
+{"time":"2025-12-20T20:05:00Z","level":"INFO","event":"game_started","code":"wxyz","restart":true,"players":5,"imposters":1,"word":"coffee"}
+{"time":"2025-12-20T20:07:00Z","level":"INFO","event":"game_ended","code":"wxyz","players":5}
+{"time":"2025-12-20T20:30:00Z","level":"INFO","event":"lobby_expired","code":"wxyz","players":5,"age":1800}
+{"broken json
+`
+
+func TestParse(t *testing.T) {
+	events, skipped, err := Parse(strings.NewReader(legacyLog + jsonLog))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	// the legacy host line isn't used, plus one junk line in each format
+	if skipped != 3 {
+		t.Errorf("Expected 3 skipped lines, got %d", skipped)
+	}
+	if len(events) != 9+13 {
+		t.Fatalf("Expected 22 events, got %d", len(events))
+	}
+
+	e := events[2]
+	if e.Name != playerJoined || e.Code != "abcd" || e.Player != "Alex" || e.Players != 2 || e.Structured {
+		t.Errorf("Unexpected legacy join: %+v", e)
+	}
+	e = events[5]
+	if e.Name != gameStarted || e.Word != "pear" || e.Imposters != 1 || !e.Restart {
+		t.Errorf("Unexpected legacy restart: %+v", e)
+	}
+	e = events[18]
+	if e.Name != roundFinished || e.Duration != 180*time.Second || e.Winner != "players" || !e.Structured {
+		t.Errorf("Unexpected round_finished: %+v", e)
+	}
+	t.Log("✓ Both log formats parse to events")
+}
+
+func TestReport(t *testing.T) {
+	events, _, err := Parse(strings.NewReader(legacyLog + jsonLog))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	r := Build(events, Options{Expiry: 15 * time.Minute})
+
+	if r.Lobbies != 3 || r.LobbiesWithGames != 2 || r.Games != 4 {
+		t.Errorf("Expected 3 lobbies, 2 with games, 4 games; got %d, %d, %d", r.Lobbies, r.LobbiesWithGames, r.Games)
+	}
+	if len(r.Days) != 2 || r.Days[0].Lobbies != 2 || r.Days[0].Games != 2 || r.Days[1].Games != 2 {
+		t.Errorf("Unexpected per-day counts: %+v", r.Days)
+	}
+	// lobbies of 3, 1 and 5 players
+	if r.AvgPlayers != 3 {
+		t.Errorf("Expected 3 players per lobby, got %v", r.AvgPlayers)
+	}
+	// games of 4m, 3m, 3m (from the logged duration) and 2m
+	if r.AvgGameDuration != 180 {
+		t.Errorf("Expected a 180s average game, got %v", r.AvgGameDuration)
+	}
+	// two games at 1 in 3 and two at 1 in 5
+	if want := (1.0/3 + 1.0/5) / 2; r.ImposterRatio-want > 1e-9 || want-r.ImposterRatio > 1e-9 {
+		t.Errorf("Expected imposter ratio %v, got %v", want, r.ImposterRatio)
+	}
+	// only the structured games record votes: 2 of 4 word players, then none
+	if r.BadVoteGames != 2 || r.BadVoteRate != 0.25 {
+		t.Errorf("Expected a 25%% bad vote rate over 2 games, got %v over %d", r.BadVoteRate, r.BadVoteGames)
+	}
+	if len(r.Words) != 2 || r.Words[0].Word != "tea" || r.Words[0].BadVoteRate != 0.5 {
+		t.Errorf("Expected tea to be voted bad most, got %+v", r.Words)
+	}
+	// abcd and efgh overlap; wxyz is a day later
+	if r.PeakLobbies != 2 {
+		t.Errorf("Expected 2 concurrent lobbies at peak, got %d", r.PeakLobbies)
+	}
+	t.Log("✓ Report counts lobbies, games, durations, ratios, votes and concurrency")
+}
+
+// TestReportRedacted tests that words logged redacted are told apart by their
+// id, and that imposters voting the word bad don't count towards the rate
+func TestReportRedacted(t *testing.T) {
+	const log = `{"time":"2025-12-21T20:00:00Z","level":"INFO","event":"game_started","code":"abcd","players":3,"imposters":1,"word":"[redacted]","word_id":"food:1f0c2a9d"}
+{"time":"2025-12-21T20:00:10Z","level":"INFO","event":"word_vote","code":"abcd","player":"A","bad":true,"votes":1,"word_votes":0,"players":3,"word":"[redacted]","word_id":"food:1f0c2a9d"}
+{"time":"2025-12-21T20:00:20Z","level":"INFO","event":"word_vote","code":"abcd","player":"B","bad":true,"votes":2,"word_votes":1,"players":3,"word":"[redacted]","word_id":"food:1f0c2a9d"}
+{"time":"2025-12-21T20:03:00Z","level":"INFO","event":"game_ended","code":"abcd","players":3}
+`
+	events, _, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	r := Build(events, Options{Expiry: 15 * time.Minute})
+
+	// one of the two word players; the imposter's vote is left out
+	if r.BadVoteGames != 1 || r.BadVoteRate != 0.5 {
+		t.Errorf("Expected a 50%% bad vote rate over 1 game, got %v over %d", r.BadVoteRate, r.BadVoteGames)
+	}
+	if len(r.Words) != 1 || r.Words[0].Word != "food:1f0c2a9d" || r.Words[0].ID != "food:1f0c2a9d" {
+		t.Errorf("Expected the redacted word reported by id, got %+v", r.Words)
+	}
+	t.Log("✓ Redacted words are reported by id, counting word players' votes")
+}
+
+func TestRunOutput(t *testing.T) {
+	path := t.TempDir() + "/lobbies.log"
+	if err := os.WriteFile(path, []byte(legacyLog+jsonLog), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := Run([]string{"-log", path, "-format", "json"}, &out); err != nil {
+		t.Fatalf("Run json: %v", err)
+	}
+	var r Report
+	if err := json.Unmarshal(out.Bytes(), &r); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if r.Games != 4 || r.SkippedLines != 3 {
+		t.Errorf("Unexpected JSON report: %+v", r)
+	}
+
+	out.Reset()
+	if err := Run([]string{path}, &out); err != nil {
+		t.Fatalf("Run table: %v", err)
+	}
+	for _, want := range []string{"Games", "Peak concurrent lobbies", "DATE", "tea"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Table output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if err := Run([]string{"-format", "xml", path}, &out); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	t.Log("✓ stats writes JSON and table reports")
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"sort"
+	"sync"
+)
+
+// LobbyStore keeps the lobbies a LobbyManager serves. The manager works on
+// the *Lobby it gets back and calls Put again after every change worth
+// keeping, so a durable store can persist it.
+type LobbyStore interface {
+	// Get returns the lobby with code, if any.
+	Get(code string) (*Lobby, bool)
+	// Put adds or updates l. Callers must hold l.mu.
+	Put(l *Lobby) error
+	// Delete removes the lobby with code.
+	Delete(code string) error
+	// List returns every lobby, ordered by code.
+	List() []*Lobby
+	// Expire removes and returns the lobbies for which expired reports true.
+	// expired is called without the store's lock held, so it may lock the
+	// lobby.
+	Expire(expired func(*Lobby) bool) ([]*Lobby, error)
+}
+
+// MemoryStore keeps lobbies in memory only; they are lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	lobbies map[string]*Lobby
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{lobbies: make(map[string]*Lobby)}
+}
+
+func (s *MemoryStore) Get(code string) (*Lobby, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.lobbies[code]
+	return l, ok
+}
+
+func (s *MemoryStore) Put(l *Lobby) error {
+	s.mu.Lock()
+	s.lobbies[l.Code] = l
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) Delete(code string) error {
+	s.mu.Lock()
+	delete(s.lobbies, code)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) List() []*Lobby {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedLobbies(s.lobbies)
+}
+
+func (s *MemoryStore) Expire(expired func(*Lobby) bool) ([]*Lobby, error) {
+	var out []*Lobby
+	for _, l := range s.List() {
+		if expired(l) {
+			s.Delete(l.Code)
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func sortedLobbies(lobbies map[string]*Lobby) []*Lobby {
+	out := make([]*Lobby, 0, len(lobbies))
+	for _, l := range lobbies {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	mRand "math/rand"
+
+	"imposter/api/protocol"
+)
+
+// Speaking order. Every round of clues the server shuffles the remaining
+// players into the order they speak in, and the turn passes down the list as
+// the current speaker (or the host) moves it on.
+
+// TurnRules are part of the wire protocol.
+type TurnRules = protocol.TurnRules
+
+// beginClues deals a new speaking order and starts the clue phase.
+// Callers must hold l.mu.
+func (m *LobbyManager) beginClues(l *Lobby) {
+	l.shuffleSpeakers()
+	l.CluePass++
+	l.sendAll(l.speakingOrder())
+	m.logEvent(EventSpeakingOrder, "code", l.Code, "order", l.SpeakingOrder)
+	m.enterPhase(l, PhaseClue)
+}
+
+// shuffleSpeakers puts the players still in the round in a random order,
+// then moves a first speaker the lobby's turn rules allow to the front. If
+// no one fits both rules, not repeating the first speaker gives way first.
+// Callers must hold l.mu.
+func (l *Lobby) shuffleSpeakers() {
+	prevFirst := ""
+	if len(l.SpeakingOrder) > 0 {
+		prevFirst = l.SpeakingOrder[0]
+	}
+
+	order := l.alivePlayers()
+	mRand.Shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+
+	allowed := func(p string, noRepeat bool) bool {
+		if l.Turns.NoImposterFirst && l.PlayerRole[p] == "imposter" {
+			return false
+		}
+		return !noRepeat || p != prevFirst
+	}
+	for _, noRepeat := range []bool{l.Turns.NoRepeatFirst, false} {
+		if i := indexWhere(order, func(p string) bool { return allowed(p, noRepeat) }); i >= 0 {
+			order[0], order[i] = order[i], order[0]
+			break
+		}
+	}
+
+	l.SpeakingOrder = order
+	l.Turn = 0
+}
+
+// indexWhere returns the index of the first element of list matching f, or -1.
+func indexWhere(list []string, f func(string) bool) int {
+	for i, s := range list {
+		if f(s) {
+			return i
+		}
+	}
+	return -1
+}
+
+// speaker is whose turn it is, "" once everyone has spoken.
+// Callers must hold l.mu.
+func (l *Lobby) speaker() string {
+	if l.Turn < len(l.SpeakingOrder) {
+		return l.SpeakingOrder[l.Turn]
+	}
+	return ""
+}
+
+// speakingOrder builds the speaking_order message. Callers must hold l.mu.
+func (l *Lobby) speakingOrder() *protocol.SpeakingOrder {
+	return &protocol.SpeakingOrder{
+		Code:  l.Code,
+		Order: append([]string{}, l.SpeakingOrder...),
+		Turn:  l.Turn,
+	}
+}
+
+func (m *LobbyManager) nextTurn(l *Lobby, name string, isHost bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.GameState != "started" || len(l.SpeakingOrder) == 0 {
+		return errors.New("no round in progress")
+	}
+	speaker := l.speaker()
+	if speaker == "" {
+		return errors.New("everyone has spoken")
+	}
+	if !isHost && name != speaker {
+		return errors.New("it is not your turn")
+	}
+	m.passTurn(l)
+	return nil
+}
+
+// passTurn moves the turn to the next speaker. Once everyone has spoken the
+// clue phase is over. Callers must hold l.mu.
+func (m *LobbyManager) passTurn(l *Lobby) {
+	l.Turn++
+	l.sendAll(&protocol.TurnChanged{Code: l.Code, Speaker: l.speaker(), Turn: l.Turn})
+	if l.speaker() == "" && l.phase != nil && l.phase.name == PhaseClue {
+		m.enterPhase(l, PhaseDiscussion)
+	}
+	m.save(l)
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"errors"
+	mRand "math/rand"
+	"sort"
+
+	"imposter/api/protocol"
+)
+
+// Imposter voting. The host opens a vote, every remaining player picks a
+// suspect, and the most voted player is eliminated and their role revealed.
+
+// Tie rules decide what happens when several suspects share the top vote count.
+const (
+	TieRevote = "revote" // vote again between the tied suspects only
+	TieNone   = "none"   // nobody is eliminated
+	TieRandom = "random" // one of the tied suspects is eliminated at random
+)
+
+type voteRound struct {
+	candidates []string
+	votes      map[string]string // voter -> suspect
+	revote     bool
+}
+
+func validTieRule(rule string) bool {
+	switch rule {
+	case TieRevote, TieNone, TieRandom:
+		return true
+	}
+	return false
+}
+
+// alivePlayers returns the players that have not been eliminated this round.
+// Callers must hold l.mu.
+func (l *Lobby) alivePlayers() []string {
+	out := []string{}
+	for _, p := range l.Players {
+		if !l.Eliminated[p] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// checkWinner returns "word" once every imposter is eliminated, "imposters"
+// once they match the remaining word players, and "" while the round goes on.
+// Callers must hold l.mu.
+func (l *Lobby) checkWinner() string {
+	imposters, words := 0, 0
+	for _, p := range l.alivePlayers() {
+		if l.PlayerRole[p] == "imposter" {
+			imposters++
+		} else {
+			words++
+		}
+	}
+	if imposters == 0 {
+		return "word"
+	}
+	if imposters >= words {
+		return "imposters"
+	}
+	return ""
+}
+
+// sendAll writes msg to every player and the host. Callers must hold l.mu.
+func (l *Lobby) sendAll(msg protocol.Message) {
+	for c := range l.clients {
+		_ = c.send(msg)
+	}
+	if l.host != nil {
+		_ = l.host.send(msg)
+	}
+}
+
+func (m *LobbyManager) openVote(l *Lobby) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return m.beginVote(l)
+}
+
+// beginVote opens a vote between the remaining players and moves the round
+// to the voting phase. Callers must hold l.mu.
+func (m *LobbyManager) beginVote(l *Lobby) error {
+	if l.GameState != "started" || l.Winner != "" {
+		return errors.New("no round in progress")
+	}
+	if l.vote != nil {
+		return errors.New("vote already open")
+	}
+	if l.guesser != "" {
+		return errors.New("waiting for the imposter to guess the word")
+	}
+
+	l.startVote(l.alivePlayers(), false)
+	m.logEvent(EventVoteOpened, "code", l.Code, "candidates", len(l.vote.candidates))
+	m.enterPhase(l, PhaseVoting)
+	return nil
+}
+
+// startVote opens a vote between candidates and tells everyone about it.
+// Callers must hold l.mu.
+func (l *Lobby) startVote(candidates []string, revote bool) {
+	l.vote = &voteRound{
+		candidates: candidates,
+		votes:      make(map[string]string),
+		revote:     revote,
+	}
+	l.sendAll(&protocol.VoteOpened{
+		Code:       l.Code,
+		Candidates: append([]string(nil), candidates...),
+		Revote:     revote,
+	})
+}
+
+func (m *LobbyManager) castVote(l *Lobby, voter, suspect string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	v := l.vote
+	if v == nil {
+		return errors.New("no vote open")
+	}
+	if l.Eliminated[voter] || !contains(l.Players, voter) {
+		return errors.New("you cannot vote")
+	}
+	if suspect == voter {
+		return errors.New("you cannot vote for yourself")
+	}
+	if !contains(v.candidates, suspect) {
+		return errors.New("unknown suspect")
+	}
+
+	v.votes[voter] = suspect
+
+	voters := len(l.alivePlayers())
+	if l.host != nil {
+		_ = l.host.send(&protocol.VoteTally{
+			Code:   l.Code,
+			Tally:  v.tally(),
+			Votes:  len(v.votes),
+			Voters: voters,
+		})
+	}
+
+	if len(v.votes) >= voters {
+		m.resolveVote(l)
+	}
+	return nil
+}
+
+func (m *LobbyManager) closeVote(l *Lobby) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.vote == nil {
+		return errors.New("no vote open")
+	}
+	m.resolveVote(l)
+	return nil
+}
+
+func (v *voteRound) tally() map[string]int {
+	tally := make(map[string]int, len(v.candidates))
+	for _, c := range v.candidates {
+		tally[c] = 0
+	}
+	for _, suspect := range v.votes {
+		tally[suspect]++
+	}
+	return tally
+}
+
+// resolveVote eliminates the top suspect, applying the lobby tie rule, and
+// broadcasts the result. Callers must hold l.mu.
+func (m *LobbyManager) resolveVote(l *Lobby) {
+	v := l.vote
+	l.vote = nil
+
+	tally := v.tally()
+	top := []string{}
+	most := 0
+	for suspect, n := range tally {
+		if n == 0 || n < most {
+			continue
+		}
+		if n > most {
+			most = n
+			top = top[:0]
+		}
+		top = append(top, suspect)
+	}
+	sort.Strings(top)
+
+	tie := len(top) > 1
+	eliminated := ""
+	switch {
+	case len(top) == 1:
+		eliminated = top[0]
+	case tie && l.TieRule == TieRevote && !v.revote:
+		m.logEvent(EventVoteTied, "code", l.Code, "tied", top)
+		l.startVote(top, true)
+		m.enterPhase(l, PhaseVoting)
+		return
+	case tie && l.TieRule == TieRandom:
+		eliminated = top[mRand.Intn(len(top))]
+	}
+
+	result := &protocol.VoteResult{
+		Code:       l.Code,
+		Tally:      tally,
+		Tie:        tie,
+		Eliminated: eliminated,
+	}
+	if eliminated != "" {
+		if l.Eliminated == nil {
+			l.Eliminated = make(map[string]bool)
+		}
+		l.Eliminated[eliminated] = true
+		role := l.PlayerRole[eliminated]
+		result.Role = role
+		if role == "imposter" {
+			// hold the outcome back until the caught imposter has had a guess
+			l.guesser = eliminated
+			l.pendingWinner = l.checkWinner()
+			result.GuessPending = true
+		} else {
+			l.Winner = l.checkWinner()
+		}
+	}
+	result.Winner = l.Winner
+	l.recordVote(v, eliminated, tally)
+	m.save(l)
+
+	m.logEvent(EventVote, "code", l.Code, "eliminated", eliminated, "role", result.Role, "tie", tie, "winner", l.Winner)
+	l.sendAll(result)
+	m.enterPhase(l, PhaseReveal)
+	if l.Winner != "" {
+		m.finishRound(l)
+	}
+}
+
+// sendError reports a rejected action back to the sender only. Errors that
+// are not already protocol errors are sent as rule rejections.
+func (l *Lobby) sendError(c *client, err error) {
+	var perr *protocol.ErrorMessage
+	if !errors.As(err, &perr) {
+		perr = protocol.Errorf(protocol.ReasonRejected, "%v", err)
+	}
+	l.mu.Lock()
+	_ = c.send(perr)
+	l.mu.Unlock()
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
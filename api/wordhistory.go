@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"imposter/api/protocol"
+)
+
+// Word history. A lobby remembers every word it deals and draws without
+// replacement, starting a new pass once its words run out. The server can
+// also keep a window of words dealt recently in any lobby, which draws avoid
+// while they have something else to choose.
+
+// recentWords is the server-wide window of recently dealt words.
+type recentWords struct {
+	mu    sync.Mutex
+	size  int      // 0 keeps nothing
+	words []string // oldest first
+}
+
+func (r *recentWords) add(word string) {
+	if r.size == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.words = append(r.words, word)
+	if len(r.words) > r.size {
+		r.words = r.words[len(r.words)-r.size:]
+	}
+}
+
+func (r *recentWords) contains(word string) bool {
+	if r.size == 0 {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return containsFold(r.words, word)
+}
+
+// usedThisPass reports whether word was dealt in the lobby's current pass
+// through its words. Callers must hold l.mu.
+func (l *Lobby) usedThisPass(word string) bool {
+	return containsFold(l.UsedWords[min(l.WordPass, len(l.UsedWords)):], word)
+}
+
+// freshIndices returns the indices of words a draw should choose from:
+// those not yet dealt this pass and not recently dealt elsewhere, else those
+// not yet dealt this pass. When every word has been dealt a new pass starts
+// and all of them are fresh again. Callers must hold l.mu.
+func (m *LobbyManager) freshIndices(l *Lobby, words []string) []int {
+	var unused, fresh []int
+	for i, w := range words {
+		if l.usedThisPass(w) {
+			continue
+		}
+		unused = append(unused, i)
+		if !m.recent.contains(w) {
+			fresh = append(fresh, i)
+		}
+	}
+	switch {
+	case len(fresh) > 0:
+		return fresh
+	case len(unused) > 0:
+		return unused
+	}
+	l.WordPass = len(l.UsedWords)
+	all := make([]int, len(words))
+	for i := range all {
+		all[i] = i
+	}
+	return all
+}
+
+// recordWord adds word to the lobby's history and the recent window.
+// Callers must hold l.mu.
+func (m *LobbyManager) recordWord(l *Lobby, word string) {
+	l.UsedWords = append(l.UsedWords, word)
+	m.recent.add(word)
+}
+
+// drawWord deals a word from pool, avoiding repeats. Callers must hold l.mu.
+func (m *LobbyManager) drawWord(l *Lobby, pool []Word) Word {
+	texts := make([]string, len(pool))
+	for i, w := range pool {
+		texts[i] = w.Text
+	}
+	idx := m.freshIndices(l, texts)
+	word := pool[idx[pickIndex(len(idx))]]
+	m.recordWord(l, word.Text)
+	return word
+}
+
+// drawPair deals a real word from groups, avoiding repeats, and a decoy from
+// the same group. Callers must hold l.mu.
+func (m *LobbyManager) drawPair(l *Lobby, groups []WordGroup) (Word, Word) {
+	type choice struct{ group, word int }
+	var choices []choice
+	var texts []string
+	for gi, g := range groups {
+		for wi, w := range g.Words {
+			choices = append(choices, choice{gi, wi})
+			texts = append(texts, w)
+		}
+	}
+	idx := m.freshIndices(l, texts)
+	c := choices[idx[pickIndex(len(idx))]]
+	g := groups[c.group]
+	j := pickIndex(len(g.Words) - 1)
+	if j >= c.word {
+		j++
+	}
+	m.recordWord(l, g.Words[c.word])
+	return Word{Text: g.Words[c.word], Category: g.Category, Pack: g.Pack},
+		Word{Text: g.Words[j], Category: g.Category, Pack: g.Pack}
+}
+
+// GetWordHistory returns the words the lobby has dealt, once no game is in
+// progress
+func (m *LobbyManager) GetWordHistory(w http.ResponseWriter, r *http.Request) {
+	l, ok := m.hostLobby(w, r)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	started := l.GameState == "started"
+	words := append([]string{}, l.UsedWords...)
+	l.mu.Unlock()
+
+	if started {
+		// the host screen is often on show to the players
+		http.Error(w, "word history is available once the game ends", http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protocol.WordHistoryResponse{Words: words})
+}
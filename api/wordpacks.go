@@ -0,0 +1,257 @@
+package api
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"imposter/api/protocol"
+)
+
+// Word packs are JSON or YAML files describing a themed list of words. The
+// default packs are embedded from wordpacks/; more can be loaded from a
+// directory named by IMPOSTER_WORDPACKS_DIR. Packs may also list groups of
+// related words, which undercover mode uses to hand imposters a decoy word.
+
+//go:embed wordpacks/*.json
+var defaultWordPackFiles embed.FS
+
+type WordPack struct {
+	Name       string   `json:"name" yaml:"name"`
+	Language   string   `json:"language" yaml:"language"`
+	Category   string   `json:"category" yaml:"category"`
+	Difficulty string   `json:"difficulty" yaml:"difficulty"` // "easy", "medium" or "hard"
+	Words      []string `json:"words" yaml:"words"`
+	// Groups are sets of similar words, e.g. ["lion", "tiger", "leopard"]
+	Groups [][]string `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// Word is a playable word and the pack it was drawn from.
+type Word struct {
+	Text     string `json:"text"`
+	Category string `json:"category"`
+	Pack     string `json:"pack"` // "" for the lobby's custom list
+}
+
+// WordGroup is a set of related words sharing their pack's category.
+type WordGroup struct {
+	Category string   `json:"category"`
+	Pack     string   `json:"pack"`
+	Words    []string `json:"words"`
+}
+
+// WordPacks is the validated set of packs available to lobbies.
+type WordPacks struct {
+	packs  []*WordPack // sorted by name
+	byName map[string]*WordPack
+}
+
+var packNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// LoadWordPacks loads the embedded packs plus any .json, .yaml or .yml packs
+// in dir.
+// dir may be empty to use the embedded packs only.
+func LoadWordPacks(dir string) (*WordPacks, error) {
+	wp := &WordPacks{byName: make(map[string]*WordPack)}
+
+	embedded, _ := fs.Sub(defaultWordPackFiles, "wordpacks")
+	if err := wp.loadDir(embedded, "embedded"); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := wp.loadDir(os.DirFS(dir), dir); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(wp.packs, func(i, j int) bool { return wp.packs[i].Name < wp.packs[j].Name })
+	return wp, nil
+}
+
+func (wp *WordPacks) loadDir(fsys fs.FS, source string) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, e := range entries {
+		f := e.Name()
+		switch ext := strings.ToLower(path.Ext(f)); {
+		case e.IsDir() || strings.HasPrefix(f, "."):
+		case ext == ".json" || ext == ".yaml" || ext == ".yml":
+			files = append(files, f)
+		default:
+			log.Printf("word packs: skipping %s, not a .json, .yaml or .yml file", path.Join(source, f))
+		}
+	}
+	for _, f := range files {
+		data, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return fmt.Errorf("word pack %s: %w", path.Join(source, f), err)
+		}
+		var p WordPack
+		unmarshal := json.Unmarshal
+		if ext := strings.ToLower(path.Ext(f)); ext == ".yaml" || ext == ".yml" {
+			unmarshal = yaml.Unmarshal
+		}
+		if err := unmarshal(data, &p); err != nil {
+			return fmt.Errorf("word pack %s: %w", path.Join(source, f), err)
+		}
+		if err := wp.add(&p); err != nil {
+			return fmt.Errorf("word pack %s: %w", path.Join(source, f), err)
+		}
+	}
+	return nil
+}
+
+// add validates p, removes duplicate words and registers it.
+func (wp *WordPacks) add(p *WordPack) error {
+	if !packNameRe.MatchString(p.Name) {
+		return fmt.Errorf("invalid name %q (use lowercase letters, digits and dashes)", p.Name)
+	}
+	if _, ok := wp.byName[p.Name]; ok {
+		return fmt.Errorf("duplicate pack name %q", p.Name)
+	}
+	if p.Language == "" {
+		p.Language = "en"
+	}
+	switch p.Difficulty {
+	case "":
+		p.Difficulty = "medium"
+	case "easy", "medium", "hard":
+	default:
+		return fmt.Errorf("difficulty must be easy, medium or hard, got %q", p.Difficulty)
+	}
+
+	for i, g := range p.Groups {
+		g = dedupeWords(g)
+		if len(g) < 2 {
+			return fmt.Errorf("group %d in pack %q needs at least two different words", i+1, p.Name)
+		}
+		p.Groups[i] = g
+		// every grouped word is also playable on its own
+		p.Words = append(p.Words, g...)
+	}
+
+	p.Words = dedupeWords(p.Words)
+	if len(p.Words) == 0 {
+		return fmt.Errorf("pack %q has no words", p.Name)
+	}
+
+	wp.packs = append(wp.packs, p)
+	wp.byName[p.Name] = p
+	return nil
+}
+
+// dedupeWords trims words and drops empty and case-insensitive duplicates,
+// keeping the first spelling seen.
+func dedupeWords(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.TrimSpace(w)
+		key := strings.ToLower(w)
+		if w == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, w)
+	}
+	return out
+}
+
+// dedupePool drops case-insensitive duplicate words, keeping the category of
+// the first pack a word appeared in.
+func dedupePool(words []Word) []Word {
+	seen := make(map[string]bool, len(words))
+	out := make([]Word, 0, len(words))
+	for _, w := range words {
+		key := strings.ToLower(w.Text)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, w)
+	}
+	return out
+}
+
+// Pool returns the de-duplicated words of the named packs, or of every pack
+// when names is empty.
+func (wp *WordPacks) Pool(names []string) ([]Word, error) {
+	if len(names) == 0 {
+		for _, p := range wp.packs {
+			names = append(names, p.Name)
+		}
+	}
+	words := []Word{}
+	for _, name := range names {
+		p, ok := wp.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown word pack %q", name)
+		}
+		for _, w := range p.Words {
+			words = append(words, Word{Text: w, Category: p.Category, Pack: p.Name})
+		}
+	}
+	return dedupePool(words), nil
+}
+
+// GroupPool returns the related-word groups of the named packs, or of every
+// pack when names is empty.
+func (wp *WordPacks) GroupPool(names []string) ([]WordGroup, error) {
+	if len(names) == 0 {
+		for _, p := range wp.packs {
+			names = append(names, p.Name)
+		}
+	}
+	groups := []WordGroup{}
+	for _, name := range names {
+		p, ok := wp.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown word pack %q", name)
+		}
+		for _, g := range p.Groups {
+			groups = append(groups, WordGroup{Category: p.Category, Pack: p.Name, Words: g})
+		}
+	}
+	return groups, nil
+}
+
+func (wp *WordPacks) list() []protocol.WordPackInfo {
+	out := make([]protocol.WordPackInfo, 0, len(wp.packs))
+	for _, p := range wp.packs {
+		out = append(out, protocol.WordPackInfo{
+			Name:       p.Name,
+			Language:   p.Language,
+			Category:   p.Category,
+			Difficulty: p.Difficulty,
+			WordCount:  len(p.Words),
+			GroupCount: len(p.Groups),
+		})
+	}
+	return out
+}
+
+func pickIndex(n int) int {
+	idx, _ := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	return int(idx.Int64())
+}
+
+// ListWordPacks returns the available word packs without their words
+func (m *LobbyManager) ListWordPacks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.packs.list())
+}
@@ -8,3 +8,7 @@ require (
 )
 
 require github.com/gorilla/websocket v1.5.0
+
+require golang.org/x/text v0.40.0
+
+require gopkg.in/yaml.v3 v3.0.1
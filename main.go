@@ -1,20 +1,45 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"imposter/api"
+	"imposter/api/stats"
 )
 
 func main() {
-	addr := "127.0.0.1:8080"
-	if a := os.Getenv("IMPOSTER_ADDR"); a != "" {
-		addr = a
+	// "imposter stats" reports on the event log instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		if err := stats.Run(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalln("stats:", err)
+		}
+		return
 	}
 
-	s := api.NewAPIServer(addr)
-	if err := s.Run(); err != nil {
+	cfg := api.DefaultConfig()
+	fs := flag.NewFlagSet("imposter", flag.ExitOnError)
+	cfg.RegisterFlags(fs)
+	printConfig := fs.Bool("print-config", false, "print the effective configuration and exit")
+	fs.Parse(os.Args[1:])
+	if err := cfg.Load(fs, os.Getenv); err != nil {
+		log.Fatalln("invalid configuration:", err)
+	}
+	if *printConfig {
+		cfg.Print(os.Stdout)
+		return
+	}
+
+	// SIGINT or SIGTERM starts a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s := api.NewAPIServer(cfg)
+	if err := s.Run(ctx); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -1,3 +1,5 @@
+import { PROTOCOL_VERSION, type InboundMessage, type OutboundMessage } from "../protocol";
+
 /**
  * Get the API URL for both development and production
  * - In dev: connects to localhost:8080 (Go backend)
@@ -22,5 +24,61 @@ export function getWebSocketUrl(path: string): string {
   const proto = apiUrl.startsWith("https") ? "wss" : "ws";
   // Remove http/https protocol and replace with ws/wss
   const host = apiUrl.replace(/^https?:\/\//, "");
-  return `${proto}://${host}${path}`;
+  return `${proto}://${host}${path}?v=${PROTOCOL_VERSION}`;
+}
+
+/**
+ * Host token handed out when a lobby is created. The server also sets it as a
+ * cookie, but that cookie isn't sent cross-origin in dev, so keep a copy.
+ */
+export function setHostToken(code: string, token: string) {
+  sessionStorage.setItem(`imposter_host_${code}`, token);
+}
+
+export function getHostToken(code: string): string {
+  return sessionStorage.getItem(`imposter_host_${code}`) ?? "";
+}
+
+/** Headers authorizing a host control request for the lobby. */
+export function hostHeaders(code: string): Record<string, string> {
+  return { "X-Host-Token": getHostToken(code) };
+}
+
+/**
+ * Session token the server issues on join. Reconnecting with it resumes the
+ * same seat, so moving between pages doesn't count as a new player.
+ */
+export function setSessionToken(code: string, token: string) {
+  sessionStorage.setItem(`imposter_session_${code}`, token);
+}
+
+export function getSessionToken(code: string): string {
+  return sessionStorage.getItem(`imposter_session_${code}`) ?? "";
+}
+
+export function clearSessionToken(code: string) {
+  sessionStorage.removeItem(`imposter_session_${code}`);
+}
+
+/** An inbound message without its version, which sendMessage fills in. */
+export type Outgoing = {
+  [K in InboundMessage["type"]]: Omit<Extract<InboundMessage, { type: K }>, "v">;
+}[InboundMessage["type"]];
+
+/** Send a protocol message over the lobby socket. */
+export function sendMessage(ws: WebSocket, msg: Outgoing) {
+  ws.send(JSON.stringify({ ...msg, v: PROTOCOL_VERSION }));
+}
+
+/** Parse a message from the lobby socket. */
+export function parseMessage(data: string): OutboundMessage {
+  return JSON.parse(data) as OutboundMessage;
+}
+
+/**
+ * Reload once a restarting server should be back, so the page reconnects
+ * with the stored tokens and resumes its seat.
+ */
+export function reloadAfterRestart(eta: number) {
+  setTimeout(() => window.location.reload(), Math.max(eta, 1) * 1000);
 }
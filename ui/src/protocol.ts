@@ -0,0 +1,524 @@
+// Code generated by tsgen from api/protocol. DO NOT EDIT.
+
+export const PROTOCOL_VERSION = 1;
+export const MIN_PROTOCOL_VERSION = 1;
+
+// WebSocket messages sent by clients.
+
+/**
+ * Join is the first message on a connection. A Token from an earlier join
+ * resumes that identity; HostToken is required to join as the host.
+ */
+export interface Join {
+  type: "join";
+  v: number;
+  name: string;
+  token?: string;
+  host_token?: string;
+}
+
+/** Leave gives up the player's seat instead of holding it for a reconnect. */
+export interface Leave {
+  type: "leave";
+  v: number;
+}
+
+/** Start asks every player's client to move to the game screen. */
+export interface Start {
+  type: "start";
+  v: number;
+}
+
+/** VoteBad marks (or unmarks) the current word as a bad one. */
+export interface VoteBad {
+  type: "vote_bad";
+  v: number;
+  voted: boolean;
+}
+
+/** OpenVote starts an elimination vote. Host only. */
+export interface OpenVote {
+  type: "open_vote";
+  v: number;
+}
+
+/** CastVote votes for the player the sender suspects. */
+export interface CastVote {
+  type: "cast_vote";
+  v: number;
+  suspect: string;
+}
+
+/** CloseVote tallies the votes cast so far. Host only. */
+export interface CloseVote {
+  type: "close_vote";
+  v: number;
+}
+
+/** GuessWord is a caught imposter's guess at the word. */
+export interface GuessWord {
+  type: "guess_word";
+  v: number;
+  guess: string;
+}
+
+/** SkipGuess gives up waiting for the caught imposter's guess. Host only. */
+export interface SkipGuess {
+  type: "skip_guess";
+  v: number;
+}
+
+/** Extend keeps an idle lobby open for another full expiry period. Host only. */
+export interface Extend {
+  type: "extend";
+  v: number;
+}
+
+/** PausePhase stops the round phase clock. Host only. */
+export interface PausePhase {
+  type: "pause_phase";
+  v: number;
+}
+
+/** ResumePhase restarts a paused phase clock. Host only. */
+export interface ResumePhase {
+  type: "resume_phase";
+  v: number;
+}
+
+/** SkipPhase ends the current round phase early. Host only. */
+export interface SkipPhase {
+  type: "skip_phase";
+  v: number;
+}
+
+/**
+ * NextTurn passes the turn to the next speaker. Only the current speaker
+ * or the host may send it.
+ */
+export interface NextTurn {
+  type: "next_turn";
+  v: number;
+}
+
+/** SubmitClue is the current speaker's clue, which also ends their turn. */
+export interface SubmitClue {
+  type: "submit_clue";
+  v: number;
+  clue: string;
+}
+
+// WebSocket messages sent by the server.
+
+/** HostReady confirms the host connection and carries its session token. */
+export interface HostReady {
+  type: "host_ready";
+  v: number;
+  code: string;
+  token: string;
+}
+
+/**
+ * LobbyState lists the players in the lobby. Token is the recipient's own
+ * session token, for reconnecting.
+ */
+export interface LobbyState {
+  type: "lobby_state";
+  v: number;
+  code: string;
+  players: string[];
+  /** players whose connection dropped */
+  away: string[];
+  token: string;
+}
+
+/** JoinRejected refuses a join and suggests a name that would be accepted. */
+export interface JoinRejected {
+  type: "join_rejected";
+  v: number;
+  /** e.g. "name_taken", "name_reserved" */
+  reason: string;
+  error: string;
+  suggestion: string;
+}
+
+/**
+ * GameStarted deals a player their role and word. The host gets the
+ * player Count instead.
+ */
+export interface GameStarted {
+  type: "game_started";
+  v: number;
+  code: string;
+  /** "imposter" or "word" */
+  role?: string;
+  word?: string;
+  category?: string;
+  count?: number;
+}
+
+/** StartGame tells players' clients to move to the game screen. */
+export interface StartGame {
+  type: "start_game";
+  v: number;
+}
+
+/** WordVoteUpdate is the number of players who think the word is bad. */
+export interface WordVoteUpdate {
+  type: "word_vote_update";
+  v: number;
+  code: string;
+  count: number;
+}
+
+/** GameEnded sends everyone back to the lobby. */
+export interface GameEnded {
+  type: "game_ended";
+  v: number;
+  code: string;
+}
+
+/** PlayerStatus reports a player's connection coming or going. */
+export interface PlayerStatus {
+  type: "player_status";
+  v: number;
+  code: string;
+  name: string;
+  /** "online", "away" or "gone" */
+  status: string;
+}
+
+/** VoteOpened starts a vote between Candidates. */
+export interface VoteOpened {
+  type: "vote_opened";
+  v: number;
+  code: string;
+  candidates: string[];
+  revote: boolean;
+}
+
+/** VoteTally shows the host the running count of an open vote. */
+export interface VoteTally {
+  type: "vote_tally";
+  v: number;
+  code: string;
+  tally: Record<string, number>;
+  votes: number;
+  voters: number;
+}
+
+/** VoteResult reveals who was voted out and whether the round is over. */
+export interface VoteResult {
+  type: "vote_result";
+  v: number;
+  code: string;
+  tally: Record<string, number>;
+  tie: boolean;
+  /** "" when nobody was voted out */
+  eliminated: string;
+  role?: string;
+  /** "", "word", "imposters" */
+  winner: string;
+  /** the caught imposter may guess the word */
+  guess_pending?: boolean;
+}
+
+/** GuessResult reveals the word and whether the caught imposter guessed it. */
+export interface GuessResult {
+  type: "guess_result";
+  v: number;
+  code: string;
+  name: string;
+  guess: string;
+  correct: boolean;
+  word: string;
+  winner: string;
+}
+
+/** Scoreboard pushes the standings after each round. */
+export interface Scoreboard {
+  type: "scoreboard";
+  v: number;
+  code: string;
+  scores: ScoreEntry[];
+  rounds: RoundRecord[];
+  points_scheme: PointsScheme;
+}
+
+/**
+ * ServerRestarting warns that the server is shutting down and expects to be
+ * back within ETA seconds. The connection closes right after it; clients
+ * reconnect with their session tokens.
+ */
+export interface ServerRestarting {
+  type: "server_restarting";
+  v: number;
+  code: string;
+  /** seconds */
+  eta: number;
+}
+
+/**
+ * LobbyExpiring warns that an idle lobby will close at ExpiresAt unless
+ * there is some activity or the host extends it.
+ */
+export interface LobbyExpiring {
+  type: "lobby_expiring";
+  v: number;
+  code: string;
+  expires_at: string;
+  /** seconds */
+  expires_in: number;
+}
+
+/** LobbyExtended reports the host extending the lobby to ExpiresAt. */
+export interface LobbyExtended {
+  type: "lobby_extended";
+  v: number;
+  code: string;
+  expires_at: string;
+  /** seconds */
+  expires_in: number;
+}
+
+/** LobbyClosed is the last message before the server closes the lobby. */
+export interface LobbyClosed {
+  type: "lobby_closed";
+  v: number;
+  code: string;
+  /** "expired" */
+  reason: string;
+}
+
+/**
+ * PhaseChanged reports the round moving to a new phase, or its clock being
+ * paused or resumed. EndsAt is the server's deadline for the phase; it is
+ * nil when the phase has no time limit or the clock is paused.
+ */
+export interface PhaseChanged {
+  type: "phase_changed";
+  v: number;
+  code: string;
+  /** "clue", "discussion", "voting" or "reveal" */
+  phase: string;
+  ends_at?: string;
+  /** full length of the phase in seconds, 0 for no limit */
+  duration: number;
+  /** seconds left when the message was sent */
+  remaining: number;
+  paused: boolean;
+}
+
+/**
+ * SpeakingOrder is the order players give their clues in this round.
+ * Turn is the index in Order of the player whose turn it is.
+ */
+export interface SpeakingOrder {
+  type: "speaking_order";
+  v: number;
+  code: string;
+  order: string[];
+  turn: number;
+}
+
+/**
+ * TurnChanged passes the turn to Speaker, at index Turn of the speaking
+ * order. Speaker is "" once everyone has spoken.
+ */
+export interface TurnChanged {
+  type: "turn_changed";
+  v: number;
+  code: string;
+  speaker: string;
+  turn: number;
+}
+
+/**
+ * ClueBoard lists the clues given so far in this pass around the table, in
+ * speaking order.
+ */
+export interface ClueBoard {
+  type: "clue_board";
+  v: number;
+  code: string;
+  pass: number;
+  clues: Clue[];
+}
+
+/** ErrorMessage reports a message the server could not accept. */
+export interface ErrorMessage {
+  type: "error";
+  v: number;
+  reason: string;
+  error: string;
+}
+
+/** ScoreEntry is one player's match total. */
+export interface ScoreEntry {
+  name: string;
+  points: number;
+}
+
+/** RoundRecord is the history entry kept for each round of a match. */
+export interface RoundRecord {
+  round: number;
+  word: string;
+  /** undercover mode only */
+  decoy?: string;
+  imposters: string[];
+  clues: Clue[];
+  votes: VoteOutcome[];
+  /** "", "word", "imposters" */
+  winner: string;
+  points: Record<string, number>;
+  started_at: string;
+  ended_at: string;
+}
+
+/** PointsScheme configures how many points each outcome is worth. */
+export interface PointsScheme {
+  /** each word player when the word players win */
+  word_win: number;
+  /** each imposter when the imposters win */
+  imposter_win: number;
+  /** each vote cast against an imposter */
+  correct_vote: number;
+  /** a caught imposter who guesses the word */
+  imposter_guess: number;
+}
+
+/**
+ * Clue is one player's clue. Pass counts the times round the table within
+ * a round, from 1.
+ */
+export interface Clue {
+  name: string;
+  clue: string;
+  pass: number;
+}
+
+/** VoteOutcome records a single resolved vote within a round. */
+export interface VoteOutcome {
+  /** "" when nobody was voted out */
+  eliminated: string;
+  role?: string;
+  tally: Record<string, number>;
+}
+
+export type InboundMessage = Join | Leave | Start | VoteBad | OpenVote | CastVote | CloseVote | GuessWord | SkipGuess | Extend | PausePhase | ResumePhase | SkipPhase | NextTurn | SubmitClue;
+
+export type OutboundMessage = HostReady | LobbyState | JoinRejected | GameStarted | StartGame | WordVoteUpdate | GameEnded | PlayerStatus | VoteOpened | VoteTally | VoteResult | GuessResult | Scoreboard | ServerRestarting | LobbyExpiring | LobbyExtended | LobbyClosed | PhaseChanged | SpeakingOrder | TurnChanged | ClueBoard | ErrorMessage;
+
+// REST request and response bodies.
+
+/**
+ * CreateLobbyResponse is returned by POST /lobbies. HostToken must be sent
+ * with every host control request for the lobby.
+ */
+export interface CreateLobbyResponse {
+  code: string;
+  host_token: string;
+}
+
+/** LobbyInfo is returned by GET /lobbies/{code}. */
+export interface LobbyInfo {
+  code: string;
+  players: string[];
+  /** seconds */
+  expires_in: number;
+  expires_at: string;
+}
+
+/**
+ * StartGameRequest is the body of POST /lobbies/{code}/start and, optionally,
+ * /restart. Omitted fields keep the lobby's current settings.
+ */
+export interface StartGameRequest {
+  imposters: number;
+  /** "classic" or "undercover" */
+  mode?: string;
+  /** "off", "imposters" or "everyone" */
+  hint?: string;
+  /** "revote", "none" or "random" */
+  tie_rule?: string;
+  points?: PointsScheme;
+  timers?: PhaseTimers;
+  turns?: TurnRules;
+  /**
+   * BadWordThreshold is the percent of word players whose bad-word votes
+   * replace the word; 0 never replaces it
+   */
+  bad_word_threshold?: number;
+  packs?: string[];
+  /** CustomWords draws from the lobby's uploaded list, mixed with Packs if given */
+  custom_words?: boolean;
+}
+
+/** StatusResponse acknowledges a control request. */
+export interface StatusResponse {
+  status: string;
+}
+
+/**
+ * WordPackInfo describes a word pack without its words, as listed by
+ * GET /wordpacks.
+ */
+export interface WordPackInfo {
+  name: string;
+  language: string;
+  category: string;
+  /** "easy", "medium" or "hard" */
+  difficulty: string;
+  word_count: number;
+  group_count: number;
+}
+
+/** CustomWordsResponse is returned by GET /lobbies/{code}/words. */
+export interface CustomWordsResponse {
+  words: string[];
+}
+
+/** SetCustomWordsResponse is returned by PUT /lobbies/{code}/words. */
+export interface SetCustomWordsResponse {
+  count: number;
+  /** words dropped as empty or too long */
+  rejected: number;
+}
+
+/**
+ * WordHistoryResponse is returned by GET /lobbies/{code}/history once the
+ * game has ended.
+ */
+export interface WordHistoryResponse {
+  /** every word dealt in the lobby, in order */
+  words: string[];
+}
+
+/**
+ * ScoreboardData is the match standings, highest score first, and the
+ * history of finished rounds.
+ */
+export interface ScoreboardData {
+  code: string;
+  scores: ScoreEntry[];
+  rounds: RoundRecord[];
+  points_scheme: PointsScheme;
+}
+
+/**
+ * PhaseTimers are the round phase durations in seconds. A phase with no
+ * duration lasts until the game moves it on or the host skips it.
+ */
+export interface PhaseTimers {
+  clue: number;
+  discussion: number;
+  voting: number;
+  reveal: number;
+}
+
+/** TurnRules constrain who speaks first each round. */
+export interface TurnRules {
+  no_imposter_first: boolean;
+  /** never the same first speaker twice in a row */
+  no_repeat_first: boolean;
+}
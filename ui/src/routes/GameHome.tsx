@@ -2,7 +2,8 @@ import { createSignal } from "solid-js";
 import { useNavigate, useSearchParams } from "@solidjs/router";
 import { GameButton } from "../components/GameButton";
 import { GameInput } from "../components/GameInput";
-import { getApiUrl } from "../config/api";
+import { getApiUrl, setHostToken } from "../config/api";
+import type { CreateLobbyResponse } from "../protocol";
 
 type SearchParams = {
   code?: string;
@@ -25,8 +26,9 @@ export default function GameHome() {
         console.error("Failed to create lobby");
         return;
       }
-      const data = await res.json();
+      const data: CreateLobbyResponse = await res.json();
       console.log("Lobby created with code:", data.code);
+      setHostToken(data.code, data.host_token);
       nav(`/lobby/${data.code}`);
     } catch (err) {
       console.error("Error creating lobby:", err);
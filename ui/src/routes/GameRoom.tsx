@@ -1,7 +1,19 @@
 import { createSignal, onCleanup, onMount } from "solid-js";
 import { useParams, useNavigate, useLocation } from "@solidjs/router";
 import { GameButton } from "../components/GameButton";
-import { getApiUrl, getWebSocketUrl } from "../config/api";
+import { GameInput } from "../components/GameInput";
+import {
+  getApiUrl,
+  getHostToken,
+  getSessionToken,
+  getWebSocketUrl,
+  hostHeaders,
+  parseMessage,
+  reloadAfterRestart,
+  sendMessage,
+  setSessionToken,
+} from "../config/api";
+import type { Clue, GuessResult, PhaseChanged, ScoreboardData, VoteOpened, VoteResult, VoteTally } from "../protocol";
 
 const imgs = [
   "/img/50_emoj.png",
@@ -29,16 +41,52 @@ export default function GameRoom() {
   const [wordBadVotes, setWordBadVotes] = createSignal(0);
   const [votedBad, setVotedBad] = createSignal<boolean>(false);
   const [img, setImg] = createSignal<string>(imgs[Math.floor(Math.random() * imgs.length)]);
+  // set when the server warns the idle lobby is about to close
+  const [expiring, setExpiring] = createSignal(false);
+  // current round phase and the server's deadline for it
+  const [phase, setPhase] = createSignal<PhaseChanged | null>(null);
+  const [secondsLeft, setSecondsLeft] = createSignal<number | null>(null);
+  // who gives clues in what order this round, and whose turn it is
+  const [order, setOrder] = createSignal<string[]>([]);
+  const [turn, setTurn] = createSignal(0);
+  // clues given so far this time round the table
+  const [clues, setClues] = createSignal<Clue[]>([]);
+  const [clue, setClue] = createSignal("");
+  // the last action the server refused
+  const [actionError, setActionError] = createSignal("");
+  // the open vote, this player's ballot and, for the host, the running count
+  const [vote, setVote] = createSignal<VoteOpened | null>(null);
+  const [myVote, setMyVote] = createSignal("");
+  const [tally, setTally] = createSignal<VoteTally | null>(null);
+  // the last vote's outcome, shown until the next time round the table
+  const [result, setResult] = createSignal<VoteResult | null>(null);
+  // a caught imposter's guess at the word, and how it went
+  const [guess, setGuess] = createSignal("");
+  const [guessResult, setGuessResult] = createSignal<GuessResult | null>(null);
+  // match standings, updated after every round
+  const [scoreboard, setScoreboard] = createSignal<ScoreboardData | null>(null);
 
   let ws: WebSocket | null = null;
 
+  // count down to the deadline the server sent, or show the frozen time while paused
+  const tick = setInterval(() => {
+    const p = phase();
+    if (!p) {
+      setSecondsLeft(null);
+    } else if (p.ends_at) {
+      setSecondsLeft(Math.max(0, Math.round((new Date(p.ends_at).getTime() - Date.now()) / 1000)));
+    } else {
+      setSecondsLeft(p.paused ? p.remaining : null);
+    }
+  }, 250);
+
   function wsUrl() {
     return getWebSocketUrl(`/api/v1/ws/${code}`);
   }
 
   function endGame() {
     // tell server to end the game and return players to lobby
-    fetch(`${apiUrl}/api/v1/lobbies/${code}/end`, { method: "POST" })
+    fetch(`${apiUrl}/api/v1/lobbies/${code}/end`, { method: "POST", headers: hostHeaders(code) })
       .then((res) => {
         if (!res.ok) throw new Error("failed to end game");
         // navigate host back to lobby
@@ -54,7 +102,7 @@ export default function GameRoom() {
   function newGame() {
     setImg(imgs[Math.floor(Math.random() * imgs.length)]);
     // request server to restart the game in this lobby (reuse existing imposter count)
-    fetch(`${apiUrl}/api/v1/lobbies/${code}/restart`, { method: "POST" })
+    fetch(`${apiUrl}/api/v1/lobbies/${code}/restart`, { method: "POST", headers: hostHeaders(code) })
       .then((res) => {
         if (!res.ok) throw new Error("failed to restart game");
         // host stays in game room; players will get new game_started messages
@@ -80,8 +128,19 @@ export default function GameRoom() {
       return;
     }
 
+    // standings so far; later rounds push their own
+    try {
+      const res = await fetch(`${apiUrl}/api/v1/lobbies/${code}/scoreboard`);
+      if (res.ok) setScoreboard(await res.json());
+    } catch (err) {
+      console.error("Error loading scoreboard:", err);
+    }
+
     // include our name in the websocket URL to ensure server registers us immediately
-    ws = new WebSocket(wsUrl() + `?name=${encodeURIComponent(name)}`);
+    const auth = isHost
+      ? `&host_token=${encodeURIComponent(getHostToken(code))}`
+      : `&token=${encodeURIComponent(getSessionToken(code))}`;
+    ws = new WebSocket(wsUrl() + `&name=${encodeURIComponent(name)}` + auth);
 
     ws.onopen = () => {
       console.log("GameRoom WebSocket opened, sending join message");
@@ -90,11 +149,79 @@ export default function GameRoom() {
 
     ws.onmessage = (ev) => {
       try {
-        const msg = JSON.parse(ev.data);
+        const msg = parseMessage(ev.data);
         console.log("GameRoom received message:", msg);
+        if (msg.type === "server_restarting") {
+          console.log("Server restarting, reconnecting in", msg.eta, "seconds");
+          reloadAfterRestart(msg.eta);
+          return;
+        }
+        if (msg.type === "lobby_expiring" || msg.type === "lobby_extended") {
+          setExpiring(msg.type === "lobby_expiring");
+          return;
+        }
+        if (msg.type === "lobby_closed") {
+          console.log("Lobby closed:", msg.reason);
+          nav("/");
+          return;
+        }
+        if (msg.type === "phase_changed") {
+          setPhase(msg);
+          return;
+        }
+        if (msg.type === "speaking_order") {
+          setOrder(msg.order);
+          setTurn(msg.turn);
+          setClues([]);
+          setVote(null);
+          setResult(null);
+          setGuessResult(null);
+          return;
+        }
+        if (msg.type === "vote_opened") {
+          setVote(msg);
+          setMyVote("");
+          setTally(null);
+          setResult(null);
+          setActionError("");
+          return;
+        }
+        if (msg.type === "vote_tally") {
+          setTally(msg);
+          return;
+        }
+        if (msg.type === "vote_result") {
+          setVote(null);
+          setTally(null);
+          setResult(msg);
+          setGuess("");
+          setGuessResult(null);
+          return;
+        }
+        if (msg.type === "guess_result") {
+          setGuessResult(msg);
+          return;
+        }
+        if (msg.type === "scoreboard") {
+          setScoreboard(msg);
+          return;
+        }
+        if (msg.type === "clue_board") {
+          setClues(msg.clues);
+          setClue("");
+          setActionError("");
+          return;
+        }
+        if (msg.type === "error") {
+          setActionError(msg.error);
+          return;
+        }
+        if (msg.type === "turn_changed") {
+          setTurn(msg.turn);
+          return;
+        }
         if (msg.type === "word_vote_update") {
-          const count = typeof msg.count === "number" ? msg.count : Number(msg.count || 0);
-          setWordBadVotes(count);
+          setWordBadVotes(msg.count);
           return;
         }
         if (msg.type === "game_ended") {
@@ -110,7 +237,9 @@ export default function GameRoom() {
         if (msg.type === "game_started") {
           console.log("Game started (or restarted), role:", msg.role, "word:", msg.word);
           // Always update role/word on game_started so a restart takes effect for connected players
+          // a replaced word arrives mid-round; the phase message follows a new game
           setRole(msg.role || null);
+          setVotedBad(false);
           if (msg.word) {
             setWord(msg.word);
           } else {
@@ -120,6 +249,7 @@ export default function GameRoom() {
         // Ignore lobby_state messages in game room
         if (msg.type === "lobby_state") {
           console.log("Updating player count from lobby_state in game room");
+          if (msg.token && !isHost) setSessionToken(code, msg.token);
           const players = msg.players || [];
           setPlayerCount((players as any[]).length || 0);
         }
@@ -138,6 +268,7 @@ export default function GameRoom() {
   });
 
   onCleanup(() => {
+    clearInterval(tick);
     if (ws) ws.close();
   });
 
@@ -148,6 +279,155 @@ export default function GameRoom() {
           <h2 class="text-3xl font-bold text-gray-800 mb-2">Game</h2>
         </div>
 
+        {phase() && (
+          <div class="text-center mb-6">
+            <p class="text-sm uppercase tracking-wide text-gray-500">{phase()!.phase}</p>
+            {secondsLeft() !== null && (
+              <p class="text-3xl font-bold text-gray-800">
+                {Math.floor(secondsLeft()! / 60)}:{String(secondsLeft()! % 60).padStart(2, "0")}
+                {phase()!.paused && " (paused)"}
+              </p>
+            )}
+            {order().length > 0 && (
+              <div class="flex flex-wrap justify-center gap-2 mt-3">
+                {order().map((p, i) => (
+                  <span
+                    class={`px-3 py-1 rounded-full text-sm ${
+                      i === turn() ? "bg-blue-600 text-white font-bold" : i < turn() ? "bg-gray-100 text-gray-400" : "bg-blue-100 text-blue-800"
+                    }`}
+                  >
+                    {i + 1}. {p}
+                  </span>
+                ))}
+              </div>
+            )}
+            {clues().length > 0 && (
+              <ol class="text-left mt-3">
+                {clues().map((c) => (
+                  <li class="text-gray-700">
+                    <span class="font-semibold">{c.name}:</span> {c.clue}
+                  </li>
+                ))}
+              </ol>
+            )}
+            {turn() < order().length && isHost && (
+              <GameButton onClick={() => ws && sendMessage(ws, { type: "next_turn" })} variant="green" class="w-full mt-3">
+                Next speaker
+              </GameButton>
+            )}
+            {turn() < order().length && !isHost && order()[turn()] === name && phase()!.phase === "clue" && (
+              <div class="flex gap-3 mt-3">
+                <GameInput value={clue()} onInput={setClue} placeholder="Your clue" error={actionError()} maxlength={40} />
+                <GameButton
+                  onClick={() => ws && clue().trim() && sendMessage(ws, { type: "submit_clue", clue: clue().trim() })}
+                  variant="green"
+                >
+                  Give clue
+                </GameButton>
+              </div>
+            )}
+            {vote() && (
+              <div class="mt-4">
+                <p class="font-semibold text-gray-800 mb-2">{vote()!.revote ? "Tie! Vote again" : "Who is the imposter?"}</p>
+                {!isHost && order().includes(name) && (
+                  <div class="flex flex-wrap justify-center gap-2">
+                    {vote()!
+                      .candidates.filter((p) => p !== name)
+                      .map((p) => (
+                        <GameButton
+                          onClick={() => {
+                            if (ws) sendMessage(ws, { type: "cast_vote", suspect: p });
+                            setMyVote(p);
+                          }}
+                          variant="red"
+                          class={`px-4 ${myVote() === p ? "" : "opacity-40"}`}
+                        >
+                          {p}
+                        </GameButton>
+                      ))}
+                  </div>
+                )}
+                {isHost && tally() && (
+                  <p class="text-gray-600">
+                    {tally()!.votes}/{tally()!.voters} votes in
+                  </p>
+                )}
+                {actionError() && <p class="text-sm text-red-600 mt-2">{actionError()}</p>}
+              </div>
+            )}
+            {result() && (
+              <div class="mt-4 text-gray-800">
+                {result()!.eliminated ? (
+                  <p>
+                    <span class="font-bold">{result()!.eliminated}</span> was voted out and was{" "}
+                    {result()!.role === "imposter" ? "an imposter!" : "not an imposter."}
+                  </p>
+                ) : (
+                  <p>{result()!.tie ? "It's a tie, nobody is out." : "Nobody was voted out."}</p>
+                )}
+                {result()!.guess_pending && !guessResult() && (
+                  <p class="text-gray-600 mt-2">{result()!.eliminated} gets one guess at the word to steal the win...</p>
+                )}
+                {result()!.guess_pending && !guessResult() && result()!.eliminated === name && (
+                  <div class="flex gap-3 mt-3">
+                    <GameInput value={guess()} onInput={setGuess} placeholder="The word is..." error={actionError()} maxlength={40} />
+                    <GameButton
+                      onClick={() => ws && guess().trim() && sendMessage(ws, { type: "guess_word", guess: guess().trim() })}
+                      variant="green"
+                    >
+                      Guess
+                    </GameButton>
+                  </div>
+                )}
+                {guessResult() && (
+                  <p class="mt-2">
+                    {guessResult()!.guess
+                      ? `${guessResult()!.name} guessed "${guessResult()!.guess}", ${guessResult()!.correct ? "correct!" : "wrong."}`
+                      : `${guessResult()!.name} didn't guess.`}{" "}
+                    The word was <span class="font-bold">{guessResult()!.word}</span>.
+                  </p>
+                )}
+                {(guessResult()?.winner || result()!.winner) && (
+                  <p class="text-2xl font-bold text-blue-600 mt-2">
+                    {(guessResult()?.winner || result()!.winner) === "word" ? "The word players win!" : "The imposters win!"}
+                  </p>
+                )}
+              </div>
+            )}
+            {isHost && (
+              <div class="flex gap-3 mt-3">
+                {result()?.guess_pending && !guessResult() && (
+                  <GameButton onClick={() => ws && sendMessage(ws, { type: "skip_guess" })} variant="secondary" class="flex-1">
+                    Skip guess
+                  </GameButton>
+                )}
+                {!vote() && !result()?.winner && !guessResult()?.winner && !(result()?.guess_pending && !guessResult()) && (
+                  <GameButton onClick={() => ws && sendMessage(ws, { type: "open_vote" })} variant="red" class="flex-1">
+                    Vote
+                  </GameButton>
+                )}
+                {vote() && (
+                  <GameButton onClick={() => ws && sendMessage(ws, { type: "close_vote" })} variant="red" class="flex-1">
+                    Close vote
+                  </GameButton>
+                )}
+                {phase()!.duration > 0 && (
+                  <GameButton
+                    onClick={() => ws && sendMessage(ws, { type: phase()!.paused ? "resume_phase" : "pause_phase" })}
+                    variant="secondary"
+                    class="flex-1"
+                  >
+                    {phase()!.paused ? "Resume" : "Pause"}
+                  </GameButton>
+                )}
+                <GameButton onClick={() => ws && sendMessage(ws, { type: "skip_phase" })} variant="secondary" class="flex-1">
+                  Skip
+                </GameButton>
+              </div>
+            )}
+          </div>
+        )}
+
         {isHost ? (
           // Host screen (show host UI immediately, regardless of role/word)
           <div class="text-center">
@@ -160,6 +440,11 @@ export default function GameRoom() {
                 {wordBadVotes()}/{playerCount() - 1}
               </div>
             </div>
+            {expiring() && (
+              <GameButton onClick={() => ws && sendMessage(ws, { type: "extend" })} variant="green" class="w-full mb-3">
+                Lobby idle, keep it open
+              </GameButton>
+            )}
             <div class="flex gap-3">
               <GameButton onClick={endGame} variant="red" class="flex-1">
                 End Game
@@ -194,7 +479,7 @@ export default function GameRoom() {
                 onClick={() => {
                   // vote that the word is bad
                   if (ws) {
-                    ws.send(JSON.stringify({ type: "vote_bad", voted: true }));
+                    sendMessage(ws, { type: "vote_bad", voted: true });
                   }
                   setVotedBad(true);
                 }}
@@ -207,7 +492,7 @@ export default function GameRoom() {
                 onClick={() => {
                   // remove word is bad vote
                   if (ws) {
-                    ws.send(JSON.stringify({ type: "vote_bad", voted: false }));
+                    sendMessage(ws, { type: "vote_bad", voted: false });
                   }
                   setVotedBad(false);
                 }}
@@ -219,6 +504,22 @@ export default function GameRoom() {
             </div>
           </div>
         )}
+
+        {scoreboard() && scoreboard()!.rounds.length > 0 && (
+          <div class="mt-8">
+            <h4 class="font-semibold text-gray-800 mb-2">
+              Scores after {scoreboard()!.rounds.length} round{scoreboard()!.rounds.length !== 1 ? "s" : ""}
+            </h4>
+            <ol>
+              {scoreboard()!.scores.map((e) => (
+                <li class={`flex justify-between py-1 ${e.name === name ? "font-bold" : ""}`}>
+                  <span>{e.name}</span>
+                  <span>{e.points}</span>
+                </li>
+              ))}
+            </ol>
+          </div>
+        )}
       </div>
     </div>
   );
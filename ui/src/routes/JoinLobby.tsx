@@ -1,7 +1,16 @@
 import { createSignal, onCleanup, onMount } from "solid-js";
 import { useParams, useNavigate, useLocation } from "@solidjs/router";
 import { GameButton } from "../components/GameButton";
-import { getApiUrl, getWebSocketUrl } from "../config/api";
+import {
+  clearSessionToken,
+  getApiUrl,
+  getSessionToken,
+  getWebSocketUrl,
+  parseMessage,
+  reloadAfterRestart,
+  sendMessage,
+  setSessionToken,
+} from "../config/api";
 
 export default function JoinLobby() {
   const params = useParams();
@@ -11,6 +20,7 @@ export default function JoinLobby() {
   const name = new URLSearchParams(loc.search).get("name") || "Player";
   const [players, setPlayers] = createSignal<string[]>([]);
   const [isLeaving, setIsLeaving] = createSignal(false);
+  const [rejected, setRejected] = createSignal<{ error: string; suggestion: string } | null>(null);
   const apiUrl = getApiUrl();
 
   let ws: WebSocket | null = null;
@@ -22,8 +32,11 @@ export default function JoinLobby() {
   function leaveLobby() {
     setIsLeaving(true);
     if (ws) {
+      // give up the seat rather than holding it for a reconnect
+      if (ws.readyState === WebSocket.OPEN) sendMessage(ws, { type: "leave" });
       ws.close();
     }
+    clearSessionToken(code);
     nav("/");
   }
 
@@ -42,15 +55,37 @@ export default function JoinLobby() {
       return;
     }
 
-    // include name in query param so server registers us immediately
-    ws = new WebSocket(wsUrl() + `?name=${encodeURIComponent(name)}`);
+    // include name in query param so server registers us immediately; a stored
+    // session token resumes our seat instead
+    const token = getSessionToken(code);
+    ws = new WebSocket(wsUrl() + `&name=${encodeURIComponent(name)}&token=${encodeURIComponent(token)}`);
 
     ws.onmessage = (ev) => {
       try {
-        const msg = JSON.parse(ev.data);
+        const msg = parseMessage(ev.data);
         console.log("Received message:", msg);
+        if (msg.type === "server_restarting") {
+          console.log("Server restarting, reconnecting in", msg.eta, "seconds");
+          reloadAfterRestart(msg.eta);
+          return;
+        }
+        if (msg.type === "lobby_closed") {
+          console.log("Lobby closed:", msg.reason);
+          nav("/");
+          return;
+        }
+        if (msg.type === "join_rejected") {
+          setRejected({ error: msg.error, suggestion: msg.suggestion || "" });
+          return;
+        }
+        if (msg.type === "error") {
+          // e.g. joining as "Host" without the host token
+          setRejected({ error: msg.error, suggestion: "" });
+          return;
+        }
         if (msg.type === "lobby_state") {
           console.log("Updating players:", msg.players);
+          if (msg.token) setSessionToken(code, msg.token);
           setPlayers(msg.players || []);
         }
         if (msg.type === "game_started") {
@@ -111,11 +146,25 @@ export default function JoinLobby() {
           </div>
         </div>
 
-        <div class="bg-blue-50 border border-blue-200 rounded-lg p-4 mb-6">
-          <p class="text-blue-700 text-sm">
-            ✓ Successfully joined the lobby. Waiting for the game to start...
-          </p>
-        </div>
+        {rejected() ? (
+          <div class="bg-red-50 border border-red-200 rounded-lg p-4 mb-6">
+            <p class="text-red-700 text-sm">Couldn't join: {rejected()!.error}</p>
+            {rejected()!.suggestion && (
+              <a
+                class="text-red-700 text-sm font-semibold underline"
+                href={`/join/${code}?name=${encodeURIComponent(rejected()!.suggestion)}`}
+              >
+                Join as {rejected()!.suggestion}
+              </a>
+            )}
+          </div>
+        ) : (
+          <div class="bg-blue-50 border border-blue-200 rounded-lg p-4 mb-6">
+            <p class="text-blue-700 text-sm">
+              ✓ Successfully joined the lobby. Waiting for the game to start...
+            </p>
+          </div>
+        )}
 
         <GameButton onClick={leaveLobby} disabled={isLeaving()} variant="secondary" class="w-full">
           {isLeaving() ? "Leaving..." : "Leave Lobby"}
@@ -2,7 +2,16 @@ import { createSignal, onCleanup, onMount } from "solid-js";
 import { useParams, useNavigate } from "@solidjs/router";
 import { GameButton } from "../components/GameButton";
 import { GameInput } from "../components/GameInput";
-import { getApiUrl, getWebSocketUrl } from "../config/api";
+import {
+  getApiUrl,
+  getHostToken,
+  getWebSocketUrl,
+  hostHeaders,
+  parseMessage,
+  reloadAfterRestart,
+  sendMessage,
+} from "../config/api";
+import type { LobbyInfo, PhaseTimers, StartGameRequest, TurnRules, WordHistoryResponse } from "../protocol";
 import QRCodeStyling from "qr-code-styling";
 
 export default function Lobby() {
@@ -10,10 +19,24 @@ export default function Lobby() {
   const nav = useNavigate();
   const code = params.code;
   const [players, setPlayers] = createSignal<string[]>([]);
+  // players whose connection dropped; they may still come back
+  const [away, setAway] = createSignal<Set<string>>(new Set());
   const [imposters, setImposters] = createSignal("1");
   const [imposterError, setImposterError] = createSignal("");
+  // phase timers in seconds, blank for no limit
+  const [clueSecs, setClueSecs] = createSignal("");
+  const [discussionSecs, setDiscussionSecs] = createSignal("");
+  const [votingSecs, setVotingSecs] = createSignal("");
+  const [revealSecs, setRevealSecs] = createSignal("");
+  // percent of word players who must vote the word bad to replace it, blank for a majority
+  const [badWordPct, setBadWordPct] = createSignal("");
+  const [turns, setTurns] = createSignal<TurnRules>({ no_imposter_first: false, no_repeat_first: false });
   const [isStarting, setIsStarting] = createSignal(false);
+  // words dealt in earlier games of this lobby
+  const [usedWords, setUsedWords] = createSignal<string[]>([]);
   const [expiresIn, setExpiresIn] = createSignal<number | null>(null);
+  // set when the server warns the idle lobby is about to close
+  const [expiring, setExpiring] = createSignal(false);
   const apiUrl = getApiUrl();
   let qrContainer: HTMLDivElement | undefined;
 
@@ -24,6 +47,10 @@ export default function Lobby() {
     return getWebSocketUrl(`/api/v1/ws/${code}`);
   }
 
+  function extendLobby() {
+    if (ws) sendMessage(ws, { type: "extend" });
+  }
+
   async function startGame() {
     setImposterError("");
 
@@ -40,12 +67,25 @@ export default function Lobby() {
       return;
     }
 
+    const secs = (v: string) => Math.max(0, parseInt(v, 10) || 0);
+    const timers: PhaseTimers = {
+      clue: secs(clueSecs()),
+      discussion: secs(discussionSecs()),
+      voting: secs(votingSecs()),
+      reveal: secs(revealSecs()),
+    };
+
+    const req: StartGameRequest = { imposters: imposterCount, timers, turns: turns() };
+    if (badWordPct().trim()) {
+      req.bad_word_threshold = Math.min(100, secs(badWordPct()));
+    }
+
     setIsStarting(true);
     try {
       const res = await fetch(`${apiUrl}/api/v1/lobbies/${code}/start`, {
         method: "POST",
-        headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({ imposters: imposterCount }),
+        headers: { "Content-Type": "application/json", ...hostHeaders(code) },
+        body: JSON.stringify(req),
       });
 
       if (!res.ok) {
@@ -72,7 +112,7 @@ export default function Lobby() {
         nav("/");
         return;
       }
-      const data = await res.json();
+      const data: LobbyInfo = await res.json();
       setExpiresIn(data.expires_in);
     } catch (err) {
       console.error("Error checking lobby:", err);
@@ -80,6 +120,17 @@ export default function Lobby() {
       return;
     }
 
+    // between games the host can see which words have been played
+    try {
+      const res = await fetch(`${apiUrl}/api/v1/lobbies/${code}/history`, { headers: hostHeaders(code) });
+      if (res.ok) {
+        const data: WordHistoryResponse = await res.json();
+        setUsedWords(data.words || []);
+      }
+    } catch (err) {
+      console.error("Error loading word history:", err);
+    }
+
     // Update expiry countdown every second
     expiryInterval = setInterval(() => {
       setExpiresIn((prev) => {
@@ -115,7 +166,7 @@ export default function Lobby() {
     }
 
     // include name in query param so server registers host immediately
-    ws = new WebSocket(wsUrl() + `?name=Host`);
+    ws = new WebSocket(wsUrl() + `&name=Host&host_token=${encodeURIComponent(getHostToken(code))}`);
 
     ws.onopen = () => {
       console.log("Lobby WebSocket opened (host via query param)");
@@ -123,8 +174,23 @@ export default function Lobby() {
 
     ws.onmessage = (ev) => {
       try {
-        const msg = JSON.parse(ev.data);
+        const msg = parseMessage(ev.data);
         console.log("Lobby received message:", msg);
+        if (msg.type === "server_restarting") {
+          console.log("Server restarting, reconnecting in", msg.eta, "seconds");
+          reloadAfterRestart(msg.eta);
+          return;
+        }
+        if (msg.type === "lobby_expiring" || msg.type === "lobby_extended") {
+          setExpiresIn(msg.expires_in);
+          setExpiring(msg.type === "lobby_expiring");
+          return;
+        }
+        if (msg.type === "lobby_closed") {
+          console.log("Lobby closed:", msg.reason);
+          nav("/");
+          return;
+        }
         if (msg.type === "host_ready") {
           console.log("Host connection ready");
           // Host is ready, just confirm connection
@@ -132,6 +198,13 @@ export default function Lobby() {
         if (msg.type === "lobby_state") {
           console.log("Updating lobby players:", msg.players);
           setPlayers(msg.players || []);
+          setAway(new Set(msg.away || []));
+        }
+        if (msg.type === "player_status") {
+          const next = new Set(away());
+          if (msg.status === "away") next.add(msg.name);
+          else next.delete(msg.name);
+          setAway(next);
         }
         if (msg.type === "game_started") {
           console.log("Game started via WebSocket");
@@ -163,10 +236,15 @@ export default function Lobby() {
         <div class="text-center mb-8">
           <h2 class="text-3xl font-bold text-gray-800 mb-2">Lobby</h2>
           <p class="text-gray-500 text-sm">You are the host</p>
-          {expiresIn() !== null && (
-            <p class="text-xs text-amber-600 mt-2 font-semibold">
-              Server expiring in {Math.max(0, expiresIn()!)}s
-            </p>
+          {expiring() && expiresIn() !== null && (
+            <div class="mt-2">
+              <p class="text-xs text-amber-600 font-semibold">
+                Lobby closing in {Math.max(0, expiresIn()!)}s for inactivity
+              </p>
+              <GameButton onClick={extendLobby} variant="green" class="w-full mt-2">
+                Keep Lobby Open
+              </GameButton>
+            </div>
           )}
         </div>
 
@@ -188,8 +266,14 @@ export default function Lobby() {
               <span class="text-gray-400 text-sm">Waiting for players to join...</span>
             ) : (
               players().map((p) => (
-                <span class="inline-block bg-blue-100 text-blue-800 px-3 py-1 rounded-full text-sm font-medium mr-2">
+                <span
+                  class={`inline-block bg-blue-100 text-blue-800 px-3 py-1 rounded-full text-sm font-medium mr-2 ${
+                    away().has(p) ? "opacity-50" : ""
+                  }`}
+                  title={away().has(p) ? "Disconnected, waiting for them to come back" : undefined}
+                >
                   {p}
+                  {away().has(p) && " (away)"}
                 </span>
               ))
             )}
@@ -213,6 +297,49 @@ export default function Lobby() {
           )}
         </div>
 
+        {usedWords().length > 0 && (
+          <div class="mb-6">
+            <p class="text-gray-700 font-semibold mb-2">Words played so far</p>
+            <p class="text-sm text-gray-600">{usedWords().join(", ")}</p>
+          </div>
+        )}
+
+        <div class="mb-6">
+          <p class="block text-sm font-semibold text-gray-700 mb-2">Phase timers (seconds, blank for no limit)</p>
+          <div class="grid grid-cols-2 gap-3">
+            <GameInput value={clueSecs()} onInput={setClueSecs} placeholder="Clues" type="number" />
+            <GameInput value={discussionSecs()} onInput={setDiscussionSecs} placeholder="Discussion" type="number" />
+            <GameInput value={votingSecs()} onInput={setVotingSecs} placeholder="Voting" type="number" />
+            <GameInput value={revealSecs()} onInput={setRevealSecs} placeholder="Reveal" type="number" />
+          </div>
+        </div>
+
+        <div class="mb-6 text-sm text-gray-700">
+          <label class="flex items-center gap-2 mb-1">
+            <input
+              type="checkbox"
+              checked={turns().no_imposter_first}
+              onChange={(e) => setTurns({ ...turns(), no_imposter_first: e.currentTarget.checked })}
+            />
+            An imposter never speaks first
+          </label>
+          <label class="flex items-center gap-2">
+            <input
+              type="checkbox"
+              checked={turns().no_repeat_first}
+              onChange={(e) => setTurns({ ...turns(), no_repeat_first: e.currentTarget.checked })}
+            />
+            Nobody speaks first twice in a row
+          </label>
+        </div>
+
+        <div class="mb-6">
+          <p class="block text-sm font-semibold text-gray-700 mb-2">
+            Replace the word when this % of word players vote it bad (blank for a majority, 0 for never)
+          </p>
+          <GameInput value={badWordPct()} onInput={setBadWordPct} placeholder="Majority" type="number" />
+        </div>
+
         <GameButton onClick={startGame} disabled={isStarting() || players().length === 0} class="w-full">
           {isStarting() ? "Starting..." : "Start Game"}
         </GameButton>